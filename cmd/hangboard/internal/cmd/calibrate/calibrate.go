@@ -0,0 +1,237 @@
+package calibrate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/config"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/loadcell"
+	"github.com/spf13/cobra"
+	"periph.io/x/periph/conn/physic"
+)
+
+var (
+	errNoMasses = errors.New("no masses were hung")
+	errTooNoisy = errors.New("too many bad reads from the load cell")
+	errFitFlags = errors.New("--quadratic and --piecewise are exclusive")
+)
+
+var calibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: "Calibrate the load cell",
+	Long: `Calibrate the load cell by hanging known masses from the board.
+The board is tared, then each mass is hung in turn and a
+calibration is fitted to the averaged readings. The calibration
+is saved to the config file and used by workouts.`,
+	RunE: doCalibrate,
+}
+
+func AddCommands(rootCmd *cobra.Command) {
+	errutil.PanicOnErr(flags(calibrateCmd))
+	rootCmd.AddCommand(calibrateCmd)
+}
+
+func doCalibrate(cmd *cobra.Command, args []string) error {
+	masses, err := cmd.Flags().GetFloat64Slice(flagMass)
+	if err != nil {
+		return err
+	}
+	samples, err := cmd.Flags().GetInt(flagSamples)
+	if err != nil {
+		return err
+	}
+	degree := 1
+	if quadratic, err := cmd.Flags().GetBool(flagQuadratic); err != nil {
+		return err
+	} else if quadratic {
+		degree = 2
+	}
+	piecewise, err := cmd.Flags().GetBool(flagPiecewise)
+	if err != nil {
+		return err
+	}
+	if piecewise && degree > 1 {
+		return errFitFlags
+	}
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return err
+	}
+
+	hx, rate, err := shared.SetupHx711(cmd)
+	if err != nil {
+		return err
+	}
+	defer hx.Halt()
+
+	w := &wizard{
+		ctx:     cmd.Context(),
+		hx:      hx,
+		in:      bufio.NewScanner(cmd.InOrStdin()),
+		out:     cmd.OutOrStdout(),
+		samples: samples,
+	}
+	zero, points, err := w.run(masses)
+	if err != nil {
+		return err
+	}
+	fit, err := loadcell.FitCalibration(points, degree)
+	if err != nil {
+		return err
+	}
+	report(w.out, points, fit)
+
+	// interpolating makes the residuals zero by construction, so the
+	// fit is still reported to show how linear the load cell is
+	var calibration loadcell.Calibration = fit.Calibration
+	if piecewise {
+		if calibration, err = loadcell.NewPiecewiseCalibration(points); err != nil {
+			return err
+		}
+		fmt.Fprintf(w.out, "interpolating between %d masses and zero\n", len(points))
+	}
+	spec, _ := loadcell.SpecOf(calibration)
+
+	if dryRun {
+		return nil
+	}
+	if err := config.SaveCalibration(config.CalibrationConfig{
+		Spec:       spec,
+		SampleRate: int(rate),
+		Zero:       &zero,
+		Time:       time.Now(),
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintln(w.out, "calibration saved")
+	return nil
+}
+
+// wizard walks the user through hanging each mass
+type wizard struct {
+	ctx     context.Context
+	hx      hx711.V2
+	in      *bufio.Scanner
+	out     io.Writer
+	samples int
+}
+
+// run returns the reading with no load and the reading with each mass
+// hung, less that
+func (w *wizard) run(masses []float64) (float64, []loadcell.CalibrationPoint, error) {
+	if err := w.prompt("Remove all weight from the board, then press enter"); err != nil {
+		return 0, nil, err
+	}
+	zero, err := w.average()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var points []loadcell.CalibrationPoint
+	for i := 0; ; i++ {
+		var kg float64
+		if len(masses) > 0 {
+			if i == len(masses) {
+				break
+			}
+			kg = masses[i]
+			if err := w.prompt(fmt.Sprintf("Hang %.2fkg from the board, then press enter", kg)); err != nil {
+				return 0, nil, err
+			}
+		} else {
+			var ok bool
+			if kg, ok, err = w.promptMass(); err != nil {
+				return 0, nil, err
+			} else if !ok {
+				break
+			}
+		}
+		raw, err := w.average()
+		if err != nil {
+			return 0, nil, err
+		}
+		points = append(points, loadcell.CalibrationPoint{
+			Raw:   raw - zero,
+			Force: physic.Force(kg * float64(physic.EarthGravity)),
+		})
+	}
+	if len(points) == 0 {
+		return 0, nil, errNoMasses
+	}
+	return zero, points, nil
+}
+
+func (w *wizard) prompt(msg string) error {
+	fmt.Fprint(w.out, msg+": ")
+	if !w.in.Scan() {
+		if err := w.in.Err(); err != nil {
+			return err
+		}
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// promptMass asks for the next mass to hang, returning false once the
+// user has finished
+func (w *wizard) promptMass() (float64, bool, error) {
+	for {
+		if err := w.prompt("Hang a known mass and enter it in kg, or press enter to finish"); err != nil {
+			return 0, false, err
+		}
+		line := strings.TrimSpace(w.in.Text())
+		if line == "" {
+			return 0, false, nil
+		}
+		kg, err := strconv.ParseFloat(strings.TrimSuffix(line, "kg"), 64)
+		if err == nil && kg > 0 {
+			return kg, true, nil
+		}
+		fmt.Fprintf(w.out, "%q is not a mass in kg\n", line)
+	}
+}
+
+// average returns the mean of the next readings, skipping bad reads
+func (w *wizard) average() (float64, error) {
+	fmt.Fprintf(w.out, "reading...")
+	defer fmt.Fprintln(w.out)
+	var total int64
+	n, bad := 0, 0
+	for n < w.samples {
+		r, err := w.hx.Read(w.ctx)
+		switch err {
+		case nil:
+		case hx711.ErrBadRead:
+			bad++
+			if bad > w.samples {
+				return 0, errTooNoisy
+			}
+			continue
+		default:
+			return 0, err
+		}
+		total += int64(r.Raw)
+		n++
+	}
+	return float64(total) / float64(n), nil
+}
+
+func report(out io.Writer, points []loadcell.CalibrationPoint, fit loadcell.Fit) {
+	kg := func(f physic.Force) float64 {
+		return float64(f) / float64(physic.EarthGravity)
+	}
+	fmt.Fprintf(out, "calibration: %v\n", fit.Calibration)
+	for i, p := range points {
+		fmt.Fprintf(out, "%8.2fkg  raw %10.0f  residual %+.3fkg\n", kg(p.Force), p.Raw, kg(fit.Residuals[i]))
+	}
+	fmt.Fprintf(out, "linearity error: %.3f%% of full scale\n", 100*fit.LinearityError)
+}
@@ -0,0 +1,24 @@
+package calibrate
+
+import (
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagMass      = "mass"
+	flagSamples   = "samples"
+	flagQuadratic = "quadratic"
+	flagPiecewise = "piecewise"
+	flagDryRun    = "dry-run"
+)
+
+func flags(cmd *cobra.Command) error {
+	shared.AddFlags(cmd)
+	cmd.Flags().Float64SliceP(flagMass, "m", nil, "known masses in kg to hang, in order; prompted for if not given")
+	cmd.Flags().IntP(flagSamples, "n", 50, "number of readings to average at each step")
+	cmd.Flags().Bool(flagQuadratic, false, "fit a quadratic rather than a linear calibration")
+	cmd.Flags().Bool(flagPiecewise, false, "interpolate between the hung masses rather than fitting a single curve")
+	cmd.Flags().Bool(flagDryRun, false, "report the calibration without saving it")
+	return nil
+}
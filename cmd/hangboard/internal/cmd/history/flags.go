@@ -0,0 +1,18 @@
+package history
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagGap    = "gap"
+	flagDryRun = "dry-run"
+)
+
+func importFlags(cmd *cobra.Command) error {
+	cmd.Flags().Duration(flagGap, 15*time.Minute, "longest break between recordings of the same session")
+	cmd.Flags().Bool(flagDryRun, false, "report the sessions found without importing them")
+	return nil
+}
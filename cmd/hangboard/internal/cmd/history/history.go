@@ -0,0 +1,15 @@
+package history
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Manage the history of workouts",
+}
+
+func AddCommands(rootCmd *cobra.Command) {
+	addImportCommand(historyCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,275 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/spf13/cobra"
+)
+
+const sessionFile = "session.json"
+
+var importCmd = &cobra.Command{
+	Use:   "import [dir]",
+	Short: "Import recorded workouts into the history database",
+	Long: `Import the CSV recordings of workouts in a directory, by default
+the one workouts are recorded in, into the history database.
+
+Recordings made in a session directory are imported into that
+session. Older recordings are grouped into sessions by how close
+together they were recorded. Recordings which have been imported
+before are skipped, so importing again only adds new recordings.
+Files which can't be read are reported and skipped.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: doImport,
+}
+
+func addImportCommand(historyCmd *cobra.Command) {
+	errutil.PanicOnErr(importFlags(importCmd))
+	historyCmd.AddCommand(importCmd)
+}
+
+// protocols are the protocols of sessions of a single kind of
+// interval, named as when they are recorded
+var protocols = map[string]string{
+	interval.KindStatic:        "max-hang",
+	interval.KindMaxTest:       "test",
+	interval.KindRepeaters:     "repeaters",
+	interval.KindCriticalForce: "critical-force",
+	interval.KindExplosivePull: "rfd",
+}
+
+// recording is a recording read for import
+type recording struct {
+	data.Recording
+	kind string
+}
+
+// importedSession is a session and the recordings to import into it
+type importedSession struct {
+	record     session.Record
+	recordings []recording
+}
+
+func doImport(cmd *cobra.Command, args []string) error {
+	dir, err := shared.OutputDir()
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	gap, err := cmd.Flags().GetDuration(flagGap)
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return err
+	}
+
+	byDir, skipped, err := readRecordings(dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range skipped {
+		cmd.PrintErrf("skipping %s\n", s)
+	}
+	sessions, err := groupSessions(byDir, gap)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		for _, s := range sessions {
+			cmd.Printf("%s: %d recordings\n", s.record.ID, len(s.recordings))
+		}
+		cmd.Printf("%d sessions to import, %d files skipped\n", len(sessions), len(skipped))
+		return nil
+	}
+
+	db, err := shared.SetupDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	maxRecorder := history.MaxRecorder(db)
+	var sessionCount, intervalCount int
+	for _, s := range sessions {
+		intervals := make([]sqlite.ImportedInterval, len(s.recordings))
+		for i, r := range s.recordings {
+			intervals[i] = importedInterval(r)
+		}
+		imported, err := db.Import(s.record, intervals)
+		if err != nil {
+			return err
+		}
+		for _, i := range imported {
+			if err := recordMax(cmd, maxRecorder, intervals[i]); err != nil {
+				return err
+			}
+		}
+		if len(imported) > 0 {
+			sessionCount++
+			intervalCount += len(imported)
+		}
+	}
+	cmd.Printf("imported %d recordings in %d sessions, %d files skipped\n", intervalCount, sessionCount, len(skipped))
+	return nil
+}
+
+// readRecordings reads the recordings under dir, by the directory
+// they are in, and describes the files it couldn't read
+func readRecordings(dir string) (map[string][]recording, []string, error) {
+	byDir := map[string][]recording{}
+	var skipped []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".csv") {
+			return nil
+		}
+		source, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		r, err := readRecording(path)
+		if err != nil {
+			skipped = append(skipped, source+": "+err.Error())
+			return nil
+		}
+		byDir[filepath.Dir(path)] = append(byDir[filepath.Dir(path)], r)
+		return nil
+	})
+	return byDir, skipped, err
+}
+
+func readRecording(path string) (recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return recording{}, err
+	}
+	defer f.Close()
+	r, err := data.ReadCsv(path, f)
+	if err != nil {
+		return recording{}, err
+	}
+	d, err := interval.ParseDescriptor(r.Descriptor)
+	if err != nil {
+		return recording{}, err
+	}
+	return recording{Recording: r, kind: d.Kind}, nil
+}
+
+// groupSessions groups recordings into sessions. Recordings in a
+// session directory belong to its session; others are grouped with
+// those recorded less than gap before them.
+func groupSessions(byDir map[string][]recording, gap time.Duration) ([]importedSession, error) {
+	var sessions []importedSession
+	var loose []recording
+	for dir, recordings := range byDir {
+		record, err := session.Load(filepath.Join(dir, sessionFile))
+		if os.IsNotExist(err) {
+			loose = append(loose, recordings...)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		record.Intervals = nil
+		sessions = append(sessions, importedSession{record: record, recordings: recordings})
+	}
+
+	sort.Slice(loose, func(i, j int) bool {
+		return loose[i].Start.Before(loose[j].Start)
+	})
+	var group []recording
+	for i, r := range loose {
+		if i > 0 && r.Start.Sub(loose[i-1].End()) > gap {
+			sessions = append(sessions, looseSession(group))
+			group = nil
+		}
+		group = append(group, r)
+	}
+	if len(group) > 0 {
+		sessions = append(sessions, looseSession(group))
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].record.Start.Before(sessions[j].record.Start)
+	})
+	return sessions, nil
+}
+
+// looseSession makes a session of recordings found outside a session
+// directory, named for the protocol they look like
+func looseSession(recordings []recording) importedSession {
+	protocol := protocols[recordings[0].kind]
+	for _, r := range recordings[1:] {
+		if r.kind != recordings[0].kind {
+			protocol = ""
+		}
+	}
+	if protocol == "" {
+		protocol = "imported"
+	}
+	start := recordings[0].Start
+	end := recordings[0].End()
+	for _, r := range recordings[1:] {
+		if r.End().After(end) {
+			end = r.End()
+		}
+	}
+	return importedSession{
+		record: session.Record{
+			Info:  session.Info{Protocol: protocol},
+			ID:    session.ID(start, protocol),
+			Start: start,
+			End:   end,
+		},
+		recordings: recordings,
+	}
+}
+
+func importedInterval(r recording) sqlite.ImportedInterval {
+	outcome, ok := r.Outcome()
+	if !ok && r.kind == interval.KindMaxTest {
+		// recordings were only kept for workouts which finished,
+		// and a max test only finished when it succeeded
+		outcome = isometric.WorkoutOutcome{Result: isometric.Success}
+	}
+	return sqlite.ImportedInterval{
+		Interval: session.Interval{
+			Descriptor:     r.Descriptor,
+			Kind:           session.Recorded,
+			Start:          r.Start,
+			End:            r.End(),
+			Outcome:        outcome.Result,
+			BelowThreshold: outcome.BelowThreshold,
+		},
+		Samples: r.Samples,
+	}
+}
+
+// recordMax records the result of an imported max test in the history
+func recordMax(cmd *cobra.Command, recorder isometric.WorkoutRecorder, in sqlite.ImportedInterval) error {
+	u, err := recorder.Start(cmd.Context(), in.Descriptor)
+	if err != nil {
+		return err
+	}
+	defer u.Close()
+	if err := u.Write(in.Samples...); err != nil {
+		return err
+	}
+	return u.Finish(isometric.WorkoutOutcome{Result: in.Outcome, BelowThreshold: in.BelowThreshold})
+}
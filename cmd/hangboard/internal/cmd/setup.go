@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/calibrate"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/daemon"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/dev"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/history"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/version"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout"
 	"github.com/spf13/cobra"
@@ -11,8 +13,10 @@ import (
 func setup(rootCmd *cobra.Command) error {
 	// add flags...
 	workout.AddCommands(rootCmd)
+	calibrate.AddCommands(rootCmd)
 	daemon.AddCommands(rootCmd)
 	dev.AddCommands(rootCmd)
+	history.AddCommands(rootCmd)
 	version.AddCommands(rootCmd)
 	return nil
 }
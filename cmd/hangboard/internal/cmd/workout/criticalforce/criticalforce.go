@@ -0,0 +1,100 @@
+package criticalforce
+
+import (
+	"strconv"
+
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/cli"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/workout/criticalforce"
+	"github.com/spf13/cobra"
+)
+
+var criticalForceCmd = &cobra.Command{
+	Use:   "critical-force",
+	Short: "Run a critical force test",
+	Long: `Test the highest force which can be sustained through
+intermittent contractions, and the capacity to work above it.
+Pull as hard as possible on every contraction:
+
+    24 × 7" contractions on 10" centers
+
+Critical force is the mean force of the last six contractions,
+and W' is the impulse above critical force over the whole test.
+The result is kept so that endurance workouts can use thresholds
+such as --threshold 110%cf.
+`,
+	RunE: doCriticalForceTest,
+}
+
+func AddCommands(rootCmd *cobra.Command) {
+	errutil.PanicOnErr(flags(criticalForceCmd))
+	rootCmd.AddCommand(criticalForceCmd)
+}
+
+func doCriticalForceTest(cmd *cobra.Command, args []string) error {
+	var (
+		p   criticalforce.Protocol
+		err error
+	)
+	if p.On, err = cmd.Flags().GetDuration(flagOn); err != nil {
+		return err
+	}
+	if p.Off, err = cmd.Flags().GetDuration(flagOff); err != nil {
+		return err
+	}
+	if p.Reps, err = cmd.Flags().GetInt(flagReps); err != nil {
+		return err
+	}
+	workout, err := criticalforce.Workout(p)
+	if err != nil {
+		return err
+	}
+
+	ledDisplay, err := shared.SetupDisplay(cmd)
+	if err != nil {
+		return err
+	}
+	ledDisplay.Start(cmd.Context())
+	loadCell, err := shared.SetupLoadCell(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := loadCell.Tare(cmd.Context(), 20); err != nil {
+		return err
+	}
+	fileRecorder, s, err := shared.SetupOutput(cmd, loadCell, session.Info{
+		Protocol: "critical-force",
+		Parameters: map[string]string{
+			"on":   p.On.String(),
+			"off":  p.Off.String(),
+			"reps": strconv.Itoa(p.Reps),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer shared.EndSession(cmd, s)
+	store, err := shared.SetupCriticalForceHistory()
+	if err != nil {
+		return err
+	}
+	report := func(r history.CriticalForceResult) {
+		errutil.SwallowF(func() error { return criticalforce.Report(cmd.OutOrStdout(), r) })
+	}
+	recorder := data.MultiRecorder(fileRecorder, criticalforce.Recorder(store, report))
+
+	cliModel, err := cli.NewCliDisplay(cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	cliModel.Start(cmd.Context())
+
+	model := display.ModelMux(ledDisplay, cliModel)
+
+	return workout.Run(shared.SetupControls(cmd), model, loadCell, recorder)
+}
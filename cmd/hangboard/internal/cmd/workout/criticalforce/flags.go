@@ -0,0 +1,19 @@
+package criticalforce
+
+import (
+	"github.com/chewr/tension-scale/workout/criticalforce"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagOn   = "on"
+	flagOff  = "off"
+	flagReps = "reps"
+)
+
+func flags(cmd *cobra.Command) error {
+	cmd.Flags().Duration(flagOn, criticalforce.Standard.On, "length of each contraction")
+	cmd.Flags().Duration(flagOff, criticalforce.Standard.Off, "rest between contractions")
+	cmd.Flags().IntP(flagReps, "r", criticalforce.Standard.Reps, "number of contractions")
+	return nil
+}
@@ -10,7 +10,7 @@ const (
 )
 
 func flags(cmd *cobra.Command) error {
-	cmd.Flags().StringP(flagThreshold, "t", "0N", "force threshold for workout")
+	cmd.Flags().StringP(flagThreshold, "t", "0N", "force threshold for workout, or a percentage of the recent max such as 80%")
 	if err := cmd.MarkFlagRequired(flagThreshold); err != nil {
 		return err
 	}
@@ -1,15 +1,17 @@
 package maxhang
 
 import (
+	"strconv"
+
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/recording"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
 	"github.com/chewr/tension-scale/display"
 	"github.com/chewr/tension-scale/display/cli"
 	"github.com/chewr/tension-scale/errutil"
 	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/session"
 	"github.com/chewr/tension-scale/workout/maxhang"
 	"github.com/spf13/cobra"
-	"periph.io/x/periph/conn/physic"
 )
 
 var maxHangCmd = &cobra.Command{
@@ -52,34 +54,41 @@ func doWorkout(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	f := new(physic.Force)
-	if err := f.Set(threshold); err != nil {
+	f, err := shared.Threshold(cmd, threshold)
+	if err != nil {
 		return err
 	}
 	week, err := cmd.Flags().GetInt(flagWeek)
 	if err != nil {
 		return err
 	}
-	maxHangWorkout, err := maxhang.Workout(maxhang.Week(week), *f)
+	maxHangWorkout, err := maxhang.Workout(maxhang.Week(week), f)
 	if err != nil {
 		return err
 	}
-	ledDisplay, err := shared.SetupDisplay()
+	ledDisplay, err := shared.SetupDisplay(cmd)
 	if err != nil {
 		return err
 	}
 	ledDisplay.Start(cmd.Context())
-	loadCell, err := shared.SetupLoadCell()
+	loadCell, err := shared.SetupLoadCell(cmd)
 	if err != nil {
 		return err
 	}
-	if err := loadCell.Tare(cmd.Context(), 20); err != nil {
+	if _, err := loadCell.Tare(cmd.Context(), 20); err != nil {
 		return err
 	}
-	fileRecorder, err := shared.SetupOutput()
+	fileRecorder, s, err := shared.SetupOutput(cmd, loadCell, session.Info{
+		Protocol: "max-hang",
+		Parameters: map[string]string{
+			"week":      strconv.Itoa(week),
+			"threshold": f.String(),
+		},
+	})
 	if err != nil {
 		return err
 	}
+	defer shared.EndSession(cmd, s)
 
 	// TODO(rchew) reconcile cliRecorder with the cliDisplay
 	cliRecorder := recording.CliRecorder(cmd)
@@ -93,5 +102,5 @@ func doWorkout(cmd *cobra.Command, args []string) error {
 
 	model := display.ModelMux(ledDisplay, cliModel)
 
-	return maxHangWorkout.Run(cmd.Context(), model, loadCell, recorder)
+	return maxHangWorkout.Run(shared.SetupControls(cmd), model, loadCell, recorder)
 }
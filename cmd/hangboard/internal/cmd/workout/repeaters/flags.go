@@ -0,0 +1,25 @@
+package repeaters
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagThreshold = "threshold"
+	flagProtocol  = "protocol"
+	flagReps      = "reps"
+	flagSets      = "sets"
+	flagSetRest   = "set-rest"
+)
+
+func flags(cmd *cobra.Command) error {
+	cmd.Flags().StringP(flagThreshold, "t", "0N", "force threshold for workout, or a percentage of the recent max such as 80%, or of critical force such as 110%cf")
+	if err := cmd.MarkFlagRequired(flagThreshold); err != nil {
+		return err
+	}
+	cmd.Flags().StringP(flagProtocol, "p", "7:3", "seconds on and off for each rep: 7:3 or 10:5")
+	cmd.Flags().IntP(flagReps, "r", 0, "reps per set, instead of the protocol's")
+	cmd.Flags().IntP(flagSets, "s", 0, "number of sets, instead of the protocol's")
+	cmd.Flags().Duration(flagSetRest, 0, "rest between sets, instead of the protocol's")
+	return nil
+}
@@ -0,0 +1,128 @@
+package repeaters
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/recording"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/cli"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/workout/repeaters"
+	"github.com/spf13/cobra"
+)
+
+var repeatersCmd = &cobra.Command{
+	Use:   "repeaters",
+	Short: "Run a repeaters workout",
+	Long: `Repeaters, or density hangs, train strength endurance with
+sets of short hangs separated by shorter rests. The work and
+rest intervals within a set run on a fixed clock, so that each
+rep starts on time however long it takes to load the board.
+
+    7:3
+        7" work intervals on 10" centers
+        6 sets of 6 reps with 3' rest
+    10:5
+        10" work intervals on 15" centers
+        5 sets of 6 reps with 3' rest
+`,
+	RunE: doWorkout,
+}
+
+func AddCommands(rootCmd *cobra.Command) {
+	errutil.PanicOnErr(flags(repeatersCmd))
+	rootCmd.AddCommand(repeatersCmd)
+}
+
+func protocol(cmd *cobra.Command) (repeaters.Protocol, error) {
+	name, err := cmd.Flags().GetString(flagProtocol)
+	if err != nil {
+		return repeaters.Protocol{}, err
+	}
+	var p repeaters.Protocol
+	switch name {
+	case "7:3":
+		p = repeaters.SevenThree
+	case "10:5":
+		p = repeaters.TenFive
+	default:
+		return repeaters.Protocol{}, fmt.Errorf("unknown protocol %q: use 7:3 or 10:5", name)
+	}
+	if cmd.Flags().Changed(flagReps) {
+		if p.Reps, err = cmd.Flags().GetInt(flagReps); err != nil {
+			return repeaters.Protocol{}, err
+		}
+	}
+	if cmd.Flags().Changed(flagSets) {
+		if p.Sets, err = cmd.Flags().GetInt(flagSets); err != nil {
+			return repeaters.Protocol{}, err
+		}
+	}
+	if cmd.Flags().Changed(flagSetRest) {
+		if p.SetRest, err = cmd.Flags().GetDuration(flagSetRest); err != nil {
+			return repeaters.Protocol{}, err
+		}
+	}
+	return p, nil
+}
+
+func doWorkout(cmd *cobra.Command, args []string) error {
+	p, err := protocol(cmd)
+	if err != nil {
+		return err
+	}
+	threshold, err := cmd.Flags().GetString(flagThreshold)
+	if err != nil {
+		return err
+	}
+	f, err := shared.Threshold(cmd, threshold)
+	if err != nil {
+		return err
+	}
+	repeatersWorkout, err := repeaters.Workout(p, f)
+	if err != nil {
+		return err
+	}
+	ledDisplay, err := shared.SetupDisplay(cmd)
+	if err != nil {
+		return err
+	}
+	ledDisplay.Start(cmd.Context())
+	loadCell, err := shared.SetupLoadCell(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := loadCell.Tare(cmd.Context(), 20); err != nil {
+		return err
+	}
+	fileRecorder, s, err := shared.SetupOutput(cmd, loadCell, session.Info{
+		Protocol: "repeaters",
+		Parameters: map[string]string{
+			"on":        p.On.String(),
+			"off":       p.Off.String(),
+			"reps":      strconv.Itoa(p.Reps),
+			"sets":      strconv.Itoa(p.Sets),
+			"set-rest":  p.SetRest.String(),
+			"threshold": f.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer shared.EndSession(cmd, s)
+	recorder := data.MultiRecorder(fileRecorder, recording.CliRecorder(cmd))
+
+	cliModel, err := cli.NewCliDisplay(cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	cliModel.Start(cmd.Context())
+
+	model := display.ModelMux(ledDisplay, cliModel)
+
+	return repeatersWorkout.Run(shared.SetupControls(cmd), model, loadCell, recorder)
+}
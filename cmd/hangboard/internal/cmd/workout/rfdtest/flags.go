@@ -0,0 +1,19 @@
+package rfdtest
+
+import (
+	"github.com/chewr/tension-scale/workout/rfdtest"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagReps = "reps"
+	flagPull = "pull"
+	flagRest = "rest"
+)
+
+func flags(cmd *cobra.Command) error {
+	cmd.Flags().IntP(flagReps, "r", rfdtest.Standard.Reps, "number of pulls")
+	cmd.Flags().Duration(flagPull, rfdtest.Standard.Pull, "length of each pull")
+	cmd.Flags().Duration(flagRest, rfdtest.Standard.Rest, "rest between pulls")
+	return nil
+}
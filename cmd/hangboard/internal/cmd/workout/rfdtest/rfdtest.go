@@ -0,0 +1,109 @@
+package rfdtest
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/cli"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/workout/rfdtest"
+	"github.com/spf13/cobra"
+)
+
+var rfdCmd = &cobra.Command{
+	Use:   "rfd",
+	Short: "Run a rate of force development test",
+	Long: `Test how quickly force can be developed with a few short,
+explosive, maximal pulls. Stay still until each pull is cued,
+then pull as hard and as fast as possible:
+
+    5 × 3" pulls with 30" rest
+
+For each pull, the onset is found where force rises above the
+noise of the quiet board before it, and the rate of force
+development is reported over the first 50, 100 and 200ms along
+with its peak and the time to peak force. The hx711 must be
+sampling at 80SPS.
+`,
+	RunE: doRFDTest,
+}
+
+// minSampleRate is the slowest sample rate which takes several
+// samples in the shortest, 50ms, window
+const minSampleRate = hx711.Rate80SPS
+
+func AddCommands(rootCmd *cobra.Command) {
+	errutil.PanicOnErr(flags(rfdCmd))
+	rootCmd.AddCommand(rfdCmd)
+}
+
+func doRFDTest(cmd *cobra.Command, args []string) error {
+	var (
+		p   rfdtest.Protocol
+		err error
+	)
+	if p.Reps, err = cmd.Flags().GetInt(flagReps); err != nil {
+		return err
+	}
+	if p.Pull, err = cmd.Flags().GetDuration(flagPull); err != nil {
+		return err
+	}
+	if p.Rest, err = cmd.Flags().GetDuration(flagRest); err != nil {
+		return err
+	}
+	workout, err := rfdtest.Workout(p)
+	if err != nil {
+		return err
+	}
+
+	ledDisplay, err := shared.SetupDisplay(cmd)
+	if err != nil {
+		return err
+	}
+	ledDisplay.Start(cmd.Context())
+	loadCell, rate, err := shared.SetupLoadCellWithRate(cmd)
+	if err != nil {
+		return err
+	}
+	switch {
+	case rate == 0:
+		cmd.PrintErrf("can't detect the sample rate; the rate of force development needs %dSPS to be measured over 50ms\n", minSampleRate)
+	case rate < minSampleRate:
+		return fmt.Errorf("load cell is running at %dSPS, too slowly to measure the rate of force development over 50ms, which needs %dSPS", rate, minSampleRate)
+	}
+	if _, err := loadCell.Tare(cmd.Context(), 20); err != nil {
+		return err
+	}
+	fileRecorder, s, err := shared.SetupOutput(cmd, loadCell, session.Info{
+		Protocol: "rfd",
+		Parameters: map[string]string{
+			"reps": strconv.Itoa(p.Reps),
+			"pull": p.Pull.String(),
+			"rest": p.Rest.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer shared.EndSession(cmd, s)
+	store, err := shared.SetupRFDHistory()
+	if err != nil {
+		return err
+	}
+	recorder := data.MultiRecorder(fileRecorder, rfdtest.Recorder(store, cmd.OutOrStdout()))
+
+	cliModel, err := cli.NewCliDisplay(cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	cliModel.Start(cmd.Context())
+
+	model := display.ModelMux(ledDisplay, cliModel)
+
+	return workout.Run(shared.SetupControls(cmd), model, loadCell, recorder)
+}
@@ -0,0 +1,19 @@
+package run
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagFile = "file"
+	flagMax  = "max"
+)
+
+func flags(cmd *cobra.Command) error {
+	cmd.Flags().StringP(flagFile, "f", "", "workout definition file, in YAML or JSON")
+	if err := cmd.MarkFlagRequired(flagFile); err != nil {
+		return err
+	}
+	cmd.Flags().StringP(flagMax, "m", "", "max force for thresholds given as a percentage, e.g. 600N or 60kg; defaults to the best recent max test")
+	return nil
+}
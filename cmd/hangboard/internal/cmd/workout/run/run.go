@@ -0,0 +1,137 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/recording"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/cli"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/workout/plan"
+	"github.com/spf13/cobra"
+	"periph.io/x/periph/conn/physic"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run a workout from a definition file",
+	Long: `Run a workout defined in a YAML or JSON file, for example:
+
+    name: max hangs week 1
+    blocks:
+      ladder:
+        - work: {duration: 3s, threshold: 80%}
+        - rest: 30s
+        - work: {duration: 6s, threshold: 80%}
+        - rest: 30s
+        - work: {duration: 9s, threshold: 80%}
+    workout:
+      - setup: 1m
+      - block: ladder
+        sets: 3
+        set-rest: 90s
+
+Each step is one of setup, rest, max-test, work, block or
+intervals, and may be repeated with repeat, or with sets and
+set-rest. Thresholds are a force such as 400N, 90lbf or 40kg,
+or a percentage of the max given by --max, or if there is no
+--max, of the best recent max test.
+`,
+	RunE: doRun,
+}
+
+func AddCommands(rootCmd *cobra.Command) {
+	errutil.PanicOnErr(flags(runCmd))
+	rootCmd.AddCommand(runCmd)
+}
+
+func doRun(cmd *cobra.Command, args []string) error {
+	file, err := cmd.Flags().GetString(flagFile)
+	if err != nil {
+		return err
+	}
+	maxFlag, err := cmd.Flags().GetString(flagMax)
+	if err != nil {
+		return err
+	}
+	var max physic.Force
+	if maxFlag != "" {
+		if max, err = plan.ParseForce(maxFlag); err != nil {
+			return err
+		}
+	} else {
+		store, err := shared.SetupHistory(cmd)
+		if err != nil {
+			return err
+		}
+		// without a max test in the history, only plans with no
+		// percentage thresholds can be built
+		if best, err := shared.RecentMax(cmd, store); err == nil {
+			max = best.Force
+		} else if err != history.ErrNoMax {
+			return err
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p, err := plan.Load(f)
+	if err != nil {
+		return err
+	}
+	// build before touching the hardware so that mistakes in the
+	// file are reported straight away
+	workout, err := p.Build(max)
+	if err != nil {
+		return err
+	}
+
+	ledDisplay, err := shared.SetupDisplay(cmd)
+	if err != nil {
+		return err
+	}
+	ledDisplay.Start(cmd.Context())
+	loadCell, err := shared.SetupLoadCell(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := loadCell.Tare(cmd.Context(), 20); err != nil {
+		return err
+	}
+	params := map[string]string{"file": file}
+	if max > 0 {
+		params["max"] = max.String()
+	}
+	protocol := p.Name
+	if protocol == "" {
+		protocol = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	fileRecorder, s, err := shared.SetupOutput(cmd, loadCell, session.Info{
+		Protocol:   protocol,
+		Parameters: params,
+	})
+	if err != nil {
+		return err
+	}
+	defer shared.EndSession(cmd, s)
+	recorder := data.MultiRecorder(fileRecorder, recording.CliRecorder(cmd))
+
+	cliModel, err := cli.NewCliDisplay(cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	cliModel.Start(cmd.Context())
+
+	model := display.ModelMux(ledDisplay, cliModel)
+
+	return workout.Run(shared.SetupControls(cmd), model, loadCell, recorder)
+}
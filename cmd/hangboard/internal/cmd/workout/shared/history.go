@@ -0,0 +1,170 @@
+package shared
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"github.com/spf13/cobra"
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	FlagMaxTest = "max-test"
+	FlagMaxAge  = "max-age"
+
+	maxHistoryFile           = "maxes.jsonl"
+	criticalForceHistoryFile = "critical-force.jsonl"
+	rfdHistoryFile           = "rfd.jsonl"
+	databaseFile             = "history.db"
+)
+
+func addHistoryFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Duration(FlagMaxTest, 10*time.Second, "shortest max test to base percentage thresholds on")
+	cmd.PersistentFlags().Duration(FlagMaxAge, 30*24*time.Hour, "oldest max or critical force test to base percentage thresholds on")
+}
+
+// SetupHistory returns the store of max test results, which is the
+// history database, or a file alongside the workout recordings when
+// the database can't be opened
+func SetupHistory(cmd *cobra.Command) (history.MaxStore, error) {
+	db, err := SetupDatabase()
+	if err == nil {
+		return db, nil
+	}
+	cmd.PrintErrf("keeping max tests in %s instead of the history database: %v\n", maxHistoryFile, err)
+	return setupMaxFile()
+}
+
+// setupMaxFile returns the store of max test results used when there
+// is no history database
+func setupMaxFile() (history.MaxStore, error) {
+	dir, err := OutputDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return history.FileStore(filepath.Join(dir, maxHistoryFile)), nil
+}
+
+// SetupDatabase opens the history database, which is kept alongside
+// the workout recordings
+func SetupDatabase() (*sqlite.DB, error) {
+	dir, err := OutputDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return sqlite.Open(filepath.Join(dir, databaseFile))
+}
+
+// SetupCriticalForceHistory returns the store of critical force
+// test results, which is kept alongside the workout recordings
+func SetupCriticalForceHistory() (history.CriticalForceStore, error) {
+	dir, err := OutputDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return history.CriticalForceFileStore(filepath.Join(dir, criticalForceHistoryFile)), nil
+}
+
+// SetupRFDHistory returns the store of rate of force development
+// test results, which is kept alongside the workout recordings
+func SetupRFDHistory() (history.RFDStore, error) {
+	dir, err := OutputDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return history.RFDFileStore(filepath.Join(dir, rfdHistoryFile)), nil
+}
+
+// RecentMax returns the best max test in the history selected by the
+// shared flags, and tells the user which test it is
+func RecentMax(cmd *cobra.Command, store history.MaxStore) (history.MaxResult, error) {
+	d, err := cmd.Flags().GetDuration(FlagMaxTest)
+	if err != nil {
+		return history.MaxResult{}, err
+	}
+	age, err := cmd.Flags().GetDuration(FlagMaxAge)
+	if err != nil {
+		return history.MaxResult{}, err
+	}
+	best, err := store.Best(d, time.Now().Add(-age))
+	if err != nil {
+		return history.MaxResult{}, err
+	}
+	cmd.Printf("using max of %v\n", best)
+	return best, nil
+}
+
+// RecentCriticalForce returns the latest critical force test in the
+// history selected by the shared flags, and tells the user which test
+// it is
+func RecentCriticalForce(cmd *cobra.Command) (history.CriticalForceResult, error) {
+	age, err := cmd.Flags().GetDuration(FlagMaxAge)
+	if err != nil {
+		return history.CriticalForceResult{}, err
+	}
+	store, err := SetupCriticalForceHistory()
+	if err != nil {
+		return history.CriticalForceResult{}, err
+	}
+	latest, err := store.Latest(time.Now().Add(-age))
+	if err != nil {
+		return history.CriticalForceResult{}, err
+	}
+	cmd.Printf("using %v\n", latest)
+	return latest, nil
+}
+
+// Threshold parses a force, or a percentage of the recent max from
+// the history such as 80%, or of the recent critical force such as
+// 110%cf
+func Threshold(cmd *cobra.Command, s string) (physic.Force, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "%cf") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%cf"), 64)
+		if err != nil {
+			return 0, err
+		}
+		cf, err := RecentCriticalForce(cmd)
+		if err != nil {
+			return 0, err
+		}
+		return physic.Force(float64(cf.CriticalForce) * pct / 100), nil
+	}
+	if !strings.HasSuffix(s, "%") {
+		var f physic.Force
+		if err := f.Set(s); err != nil {
+			return 0, err
+		}
+		return f, nil
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	store, err := SetupHistory(cmd)
+	if err != nil {
+		return 0, err
+	}
+	max, err := RecentMax(cmd, store)
+	if err != nil {
+		return 0, err
+	}
+	return physic.Force(float64(max.Force) * pct / 100), nil
+}
@@ -1,15 +1,34 @@
 package shared
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/config"
 	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/cli"
+	"github.com/chewr/tension-scale/display/stateimpl"
 	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/hx711/replay"
+	"github.com/chewr/tension-scale/hx711/sim"
 	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
 	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"github.com/chewr/tension-scale/isometric/session"
 	"github.com/chewr/tension-scale/led"
 	"github.com/chewr/tension-scale/loadcell"
+	"github.com/chewr/tension-scale/loadcell/filter"
+	"github.com/chewr/tension-scale/version"
+	"github.com/spf13/cobra"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
 	"periph.io/x/periph/host"
 	"periph.io/x/periph/host/rpi"
 )
@@ -18,7 +37,49 @@ import (
 // TODO(rchew): make portable
 // TODO(rchew): make configurable
 
-func SetupDisplay() (display.AutoRefreshingModel, error) {
+const (
+	FlagSimulate    = "simulate"
+	FlagRecordRaw   = "record-raw"
+	FlagReplay      = "replay"
+	FlagEdgeWait    = "wait-for-edge"
+	FlagZeroTrack   = "zero-tracking"
+	FlagFilter      = "filter"
+	FlagUser        = "user"
+	FlagDualChannel = "dual-channel"
+	FlagHx711       = "hx711"
+)
+
+const sessionFile = "session.json"
+
+// AddFlags adds flags shared by all workout commands
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(FlagSimulate, false, "use a simulated load cell and no LEDs instead of hardware")
+	cmd.PersistentFlags().String(FlagRecordRaw, "", "record raw hx711 output to the given file")
+	cmd.PersistentFlags().Bool(FlagEdgeWait, false, "wait for load cell data using gpio edge detection instead of polling")
+	cmd.PersistentFlags().Bool(FlagZeroTrack, false, "correct for drift in the load cell's zero while the board is unloaded")
+	cmd.PersistentFlags().String(FlagFilter, "none", "smooth force for the display and thresholds, e.g. median:150ms,butterworth:2Hz; recordings keep raw data")
+	cmd.PersistentFlags().String(FlagReplay, "", "replay raw hx711 output from the given file instead of using hardware; implies --simulate")
+	cmd.PersistentFlags().String(FlagUser, os.Getenv("USER"), "who is training, recorded with each session")
+	cmd.PersistentFlags().StringSlice(FlagHx711, []string{"GPIO6:GPIO5"}, "clock:data gpio pins of each hx711; the force on the load cells of several is added together, and the first is used alone where one is needed")
+	cmd.PersistentFlags().Bool(FlagDualChannel, false, "add the force on a second load cell on channel B of each hx711, calibrated like the one on channel A")
+	addHistoryFlags(cmd)
+}
+
+func simulate(cmd *cobra.Command) (bool, error) {
+	if replayFile, err := cmd.Flags().GetString(FlagReplay); err != nil {
+		return false, err
+	} else if replayFile != "" {
+		return true, nil
+	}
+	return cmd.Flags().GetBool(FlagSimulate)
+}
+
+func SetupDisplay(cmd *cobra.Command) (display.AutoRefreshingModel, error) {
+	if simulated, err := simulate(cmd); err != nil {
+		return nil, err
+	} else if simulated {
+		return &nopDisplay{}, nil
+	}
 	if _, err := host.Init(); err != nil {
 		return nil, err
 	}
@@ -28,22 +89,368 @@ func SetupDisplay() (display.AutoRefreshingModel, error) {
 	return led.NewTrafficLightDisplay(grn, ylw, red)
 }
 
-func SetupLoadCell() (loadcell.Sensor, error) {
+// SetupLoadCell returns the load cell using the calibration saved by
+// `hangboard calibrate`, or the TrueSun 400KG calibration if there
+// isn't one
+func SetupLoadCell(cmd *cobra.Command) (loadcell.Sensor, error) {
+	sensor, _, err := SetupLoadCellWithRate(cmd)
+	return sensor, err
+}
+
+// SetupLoadCellWithRate returns the load cell as SetupLoadCell does,
+// along with its sample rate, which is 0 if it can't be detected
+func SetupLoadCellWithRate(cmd *cobra.Command) (loadcell.Sensor, hx711.SampleRate, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, 0, err
+	}
+	var calibration loadcell.Calibration = loadcell.TrueSun400Slow
+	calibratedRate := hx711.Rate10SPS
+	var opts []loadcell.Option
+	if c := cfg.Calibration; c != nil {
+		saved, err := c.Calibration()
+		switch err {
+		case nil:
+			calibration = saved
+			calibratedRate = hx711.SampleRate(c.SampleRate)
+			if c.Zero != nil {
+				opts = append(opts, loadcell.WithUnloadedReading(int64(math.Round(*c.Zero))))
+			}
+		case loadcell.ErrNoCalibration:
+		default:
+			return nil, 0, err
+		}
+	}
+	hxs, rate, err := setupChannels(cmd)
+	if err != nil {
+		return nil, 0, err
+	}
+	// the rate is saved as 0 when calibrating on a replayed or
+	// simulated hx711, or one whose rate can't be detected
+	if rate != 0 && calibratedRate != 0 && rate != calibratedRate {
+		cmd.PrintErrf("load cell is running at %dSPS but is calibrated for %dSPS\n", rate, calibratedRate)
+	}
+	if len(hxs) > 1 {
+		// the unloaded reading was only measured on the first
+		opts = nil
+	}
+	var cells []loadcell.Sensor
+	for _, hx := range hxs {
+		c := calibration
+		cellOpts := opts
+		if g, ok := hx.(hx711.GainReporter); ok && g.Gain() != hx711.ChannelA128 {
+			// `hangboard calibrate` uses the default gain
+			c = loadcell.AtGain(calibration, hx711.ChannelA128, g.Gain())
+			cellOpts = nil
+		}
+		cells = append(cells, loadcell.NewHx711(hx, c, cellOpts...))
+	}
+	sensor := cells[0]
+	if len(cells) > 1 {
+		sensor = loadcell.NewMulti(cells...)
+	}
+	if zeroTrack, err := cmd.Flags().GetBool(FlagZeroTrack); err != nil {
+		return nil, 0, err
+	} else if zeroTrack {
+		sensor = loadcell.NewZeroTracker(sensor)
+	}
+	filterSpec, err := cmd.Flags().GetString(FlagFilter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if f, err := filter.Parse(filterSpec); err != nil {
+		return nil, 0, err
+	} else if f != nil {
+		sensor = filter.Sensor(sensor, f)
+	}
+	return sensor, rate, nil
+}
+
+// setupChannels returns the hx711 channel of each load cell selected
+// by the shared flags, along with the sample rate as for SetupHx711
+func setupChannels(cmd *cobra.Command) ([]hx711.V2, hx711.SampleRate, error) {
+	dual, err := cmd.Flags().GetBool(FlagDualChannel)
+	if err != nil {
+		return nil, 0, err
+	}
+	pins, err := cmd.Flags().GetStringSlice(FlagHx711)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !dual && len(pins) <= 1 {
+		hx, rate, err := SetupHx711(cmd)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []hx711.V2{hx}, rate, nil
+	}
+	flag := FlagHx711
+	if dual {
+		flag = FlagDualChannel
+	}
+	if err := requireHardware(cmd, flag); err != nil {
+		return nil, 0, err
+	}
+	opts, err := hx711Options(cmd)
+	if err != nil {
+		return nil, 0, err
+	}
+	var hxs []hx711.V2
+	var rate hx711.SampleRate
+	for _, pair := range pins {
+		clk, data, err := hx711Pins(pair)
+		if err != nil {
+			return nil, 0, err
+		}
+		if dual {
+			// interleaving hides the sample rate, which is only a
+			// quarter of the device's on each channel
+			a, b, err := hx711.NewDualChannel(clk, data, opts...)
+			if err != nil {
+				return nil, 0, err
+			}
+			hxs = append(hxs, a, b)
+			continue
+		}
+		hx, err := hx711.New(clk, data, opts...)
+		if err != nil {
+			return nil, 0, err
+		}
+		if detector, ok := hx.(hx711.SampleRateDetector); ok {
+			if rate, err = detector.DetectSampleRate(cmd.Context()); err != nil {
+				return nil, 0, err
+			}
+		}
+		hxs = append(hxs, hx)
+	}
+	return hxs, rate, nil
+}
+
+// hx711Pins looks up a clock:data pair of gpio pin names
+func hx711Pins(pair string) (gpio.PinIO, gpio.PinIO, error) {
+	names := strings.Split(pair, ":")
+	if len(names) != 2 {
+		return nil, nil, fmt.Errorf("hx711 pins %q are not a clock:data pair", pair)
+	}
+	var pins [2]gpio.PinIO
+	for i, name := range names {
+		if pins[i] = gpioreg.ByName(name); pins[i] == nil {
+			return nil, nil, fmt.Errorf("no gpio pin named %q", name)
+		}
+	}
+	return pins[0], pins[1], nil
+}
+
+// requireHardware returns an error if the shared flags select a
+// simulated or recorded hx711, which can't be used with flag
+func requireHardware(cmd *cobra.Command, flag string) error {
+	if simulated, err := simulate(cmd); err != nil {
+		return err
+	} else if simulated {
+		return fmt.Errorf("--%s can't be simulated or replayed", flag)
+	}
+	if recordFile, err := cmd.Flags().GetString(FlagRecordRaw); err != nil {
+		return err
+	} else if recordFile != "" {
+		return fmt.Errorf("--%s can't be recorded with --%s", flag, FlagRecordRaw)
+	}
+	_, err := host.Init()
+	return err
+}
+
+func hx711Options(cmd *cobra.Command) ([]hx711.Option, error) {
+	var opts []hx711.Option
+	if edgeWait, err := cmd.Flags().GetBool(FlagEdgeWait); err != nil {
+		return nil, err
+	} else if edgeWait {
+		opts = append(opts, hx711.WithEdgeDetection())
+	}
+	return opts, nil
+}
+
+// SetupHx711 returns the first hx711 selected by the shared flags
+// along with its sample rate, which is 0 if it can't be detected
+func SetupHx711(cmd *cobra.Command) (hx711.V2, hx711.SampleRate, error) {
+	hx, err := openHx711(cmd)
+	if err != nil {
+		return nil, 0, err
+	}
+	var rate hx711.SampleRate
+	if detector, ok := hx.(hx711.SampleRateDetector); ok {
+		if rate, err = detector.DetectSampleRate(cmd.Context()); err != nil {
+			return nil, 0, err
+		}
+	}
+	recordFile, err := cmd.Flags().GetString(FlagRecordRaw)
+	if err != nil {
+		return nil, 0, err
+	}
+	if recordFile == "" {
+		return hx, rate, nil
+	}
+	// the file is left open for the life of the process; entries
+	// are written unbuffered so nothing is lost when it exits
+	f, err := os.Create(recordFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	recorder, err := replay.Record(hx, f)
+	return recorder, rate, err
+}
+
+func openHx711(cmd *cobra.Command) (hx711.V2, error) {
+	if replayFile, err := cmd.Flags().GetString(FlagReplay); err != nil {
+		return nil, err
+	} else if replayFile != "" {
+		f, err := os.Open(replayFile)
+		if err != nil {
+			return nil, err
+		}
+		return replay.Open(f)
+	}
+	if simulated, err := simulate(cmd); err != nil {
+		return nil, err
+	} else if simulated {
+		return simulatedHx711(), nil
+	}
 	if _, err := host.Init(); err != nil {
 		return nil, err
 	}
-	hx, err := hx711.New(rpi.P1_31, rpi.P1_29)
+	opts, err := hx711Options(cmd)
+	if err != nil {
+		return nil, err
+	}
+	pins, err := cmd.Flags().GetStringSlice(FlagHx711)
+	if err != nil {
+		return nil, err
+	}
+	if len(pins) == 0 {
+		return nil, fmt.Errorf("no --%s pins given", FlagHx711)
+	}
+	clk, data, err := hx711Pins(pins[0])
 	if err != nil {
 		return nil, err
 	}
-	return loadcell.NewHx711(hx, loadcell.TrueSun400Slow), nil
+	return hx711.New(clk, data, opts...)
+}
+
+// simulatedHx711 returns a simulated hx711 with noise and glitch
+// characteristics similar to the TrueSun 400KG load cell at 10SPS,
+// with an athlete repeatedly hanging ~70KG
+func simulatedHx711() hx711.V2 {
+	const unitsPerKilogram = 7222
+	return sim.New(
+		sim.RepeatedHang(70*unitsPerKilogram, 12*time.Second, 8*time.Second),
+		sim.WithSampleRate(hx711.Rate10SPS),
+		sim.WithOffset(-31000),
+		sim.WithNoise(400),
+		sim.WithDrift(150),
+		sim.WithGlitches(0.01),
+	)
+}
+
+// Session is a session started by SetupOutput
+type Session struct {
+	*session.Session
+	// db is nil if the history database couldn't be opened
+	db *sqlite.Recorder
+}
+
+// SetupOutput starts a session of the workout described by info and
+// returns a recorder which writes its intervals to CSV files in a
+// directory of its own, records its timeline in session.json, stores
+// it in the history database and keeps the results of max tests in
+// the history. Each file describes how loadCell was set up. The
+// session must be ended with EndSession.
+func SetupOutput(cmd *cobra.Command, loadCell loadcell.Sensor, info session.Info) (isometric.WorkoutRecorder, *Session, error) {
+	baseDir, err := OutputDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	filterSpec, err := cmd.Flags().GetString(FlagFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.User, err = cmd.Flags().GetString(FlagUser); err != nil {
+		return nil, nil, err
+	}
+	id := session.ID(time.Now(), info.Protocol)
+	dir := filepath.Join(baseDir, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	s, err := session.Start(id, info, session.FileStore(filepath.Join(dir, sessionFile)))
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := func() map[string]string {
+		m := loadcell.Describe(loadCell)
+		if m == nil {
+			m = map[string]string{}
+		}
+		m["version"] = version.GetVersion()
+		m["filter"] = filterSpec
+		return m
+	}
+	csvRecorder, err := data.CsvRecorder(dir, data.WithMetadata(metadata))
+	if err != nil {
+		return nil, nil, err
+	}
+	recorders := []isometric.WorkoutRecorder{s, csvRecorder}
+	// the database needs cgo, which cross-compiled builds lack, so
+	// workouts go on without it and keep max tests in a file instead
+	var dbRecorder *sqlite.Recorder
+	if db, err := SetupDatabase(); err != nil {
+		cmd.PrintErrf("not recording to the history database: %v\n", err)
+		store, err := setupMaxFile()
+		if err != nil {
+			return nil, nil, err
+		}
+		recorders = append(recorders, history.MaxRecorder(store))
+	} else if dbRecorder, err = db.Session(id, info, time.Now()); err != nil {
+		return nil, nil, err
+	} else {
+		recorders = append(recorders, dbRecorder, history.MaxRecorder(db))
+	}
+	return data.MultiRecorder(recorders...), &Session{Session: s, db: dbRecorder}, nil
+}
+
+// EndSession ends a session started by SetupOutput, reporting any
+// failure to save it
+func EndSession(cmd *cobra.Command, s *Session) {
+	if err := s.End(); err != nil {
+		cmd.PrintErrf("failed to save session %s: %v\n", s.ID(), err)
+	}
+	if s.db == nil {
+		return
+	}
+	if err := s.db.End(); err != nil {
+		cmd.PrintErrf("failed to store session %s in the history database: %v\n", s.ID(), err)
+	}
 }
 
-func SetupOutput() (isometric.WorkoutRecorder, error) {
+// OutputDir returns the directory workouts are recorded in
+func OutputDir() (string, error) {
 	const defaultOutputDir = "Documents/workouts"
 	homedir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return data.CsvRecorder(filepath.Join(homedir, defaultOutputDir))
+	return filepath.Join(homedir, defaultOutputDir), nil
+}
+
+// nopDisplay stands in for the LED display when there is no hardware
+type nopDisplay struct {
+	stateimpl.StateHolder
+}
+
+func (*nopDisplay) Start(context.Context) {}
+
+// SetupControls returns a context carrying controls for the workout,
+// driven from the keyboard
+func SetupControls(cmd *cobra.Command) context.Context {
+	controls := control.New()
+	cli.KeyboardControls(cmd.Context(), cmd.InOrStdin(), controls)
+	cmd.PrintErrln(cli.KeyboardHelp)
+	return control.WithControls(cmd.Context(), controls)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/chewr/tension-scale/errutil"
 	"github.com/chewr/tension-scale/isometric"
 	"github.com/chewr/tension-scale/isometric/interval"
+	"github.com/chewr/tension-scale/isometric/session"
 	"github.com/spf13/cobra"
 )
 
@@ -27,28 +28,31 @@ func AddCommands(rootCmd *cobra.Command) {
 }
 
 func doMaxTest(cmd *cobra.Command, args []string) error {
-	ledDisplay, err := shared.SetupDisplay()
+	ledDisplay, err := shared.SetupDisplay(cmd)
 	if err != nil {
 		return err
 	}
 	ledDisplay.Start(cmd.Context())
-	loadCell, err := shared.SetupLoadCell()
+	loadCell, err := shared.SetupLoadCell(cmd)
 	if err != nil {
 		return err
 	}
-	if err := loadCell.Tare(cmd.Context(), 20); err != nil {
+	if _, err := loadCell.Tare(cmd.Context(), 20); err != nil {
 		return err
 	}
-	// TODO(rchew) reconcile cli recorder and cli display
-	recorder, err := shared.SetupOutput()
+	duration, err := cmd.Flags().GetDuration(flagDuration)
 	if err != nil {
 		return err
 	}
-
-	duration, err := cmd.Flags().GetDuration(flagDuration)
+	// TODO(rchew) reconcile cli recorder and cli display
+	recorder, s, err := shared.SetupOutput(cmd, loadCell, session.Info{
+		Protocol:   "test",
+		Parameters: map[string]string{"duration": duration.String()},
+	})
 	if err != nil {
 		return err
 	}
+	defer shared.EndSession(cmd, s)
 
 	cliModel, err := cli.NewCliDisplay(cmd.OutOrStdout())
 	if err != nil {
@@ -59,7 +63,7 @@ func doMaxTest(cmd *cobra.Command, args []string) error {
 	model := display.ModelMux(ledDisplay, cliModel)
 
 	maxTestWorkout := setupMaxTestWorkout(duration)
-	return maxTestWorkout.Run(cmd.Context(), model, loadCell, recorder)
+	return maxTestWorkout.Run(shared.SetupControls(cmd), model, loadCell, recorder)
 }
 
 func setupMaxTestWorkout(d time.Duration) isometric.Workout {
@@ -1,7 +1,12 @@
 package workout
 
 import (
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/criticalforce"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/maxhang"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/repeaters"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/rfdtest"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/run"
+	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/shared"
 	"github.com/chewr/tension-scale/cmd/hangboard/internal/cmd/workout/testhang"
 	"github.com/spf13/cobra"
 )
@@ -13,8 +18,13 @@ var workoutCmd = &cobra.Command{
 
 func setup(workoutCmd *cobra.Command) {
 	// add flags...
+	shared.AddFlags(workoutCmd)
 	maxhang.AddCommands(workoutCmd)
 	testhang.AddCommands(workoutCmd)
+	repeaters.AddCommands(workoutCmd)
+	criticalforce.AddCommands(workoutCmd)
+	rfdtest.AddCommands(workoutCmd)
+	run.AddCommands(workoutCmd)
 }
 
 func AddCommands(rootCmd *cobra.Command) {
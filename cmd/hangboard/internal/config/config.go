@@ -1,3 +1,97 @@
 package config
 
-type HangboardConfig struct{}
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyCalibration = "calibration"
+
+	defaultConfigFile = ".hangboard.yaml"
+)
+
+type HangboardConfig struct {
+	Calibration *CalibrationConfig `mapstructure:"calibration"`
+}
+
+// CalibrationConfig is a load cell calibration produced by
+// `hangboard calibrate`
+type CalibrationConfig struct {
+	// Spec is the calibration, in any form loadcell can serialize
+	Spec loadcell.CalibrationSpec `mapstructure:",squash"`
+	// Coefficients are those of a loadcell.PolynomialCalibration
+	// saved before Spec was introduced
+	Coefficients []float64 `mapstructure:"coefficients"`
+	// SampleRate is the hx711 sample rate the calibration was made at
+	SampleRate int `mapstructure:"sample-rate"`
+	// Zero is the reading with nothing on the board when the
+	// calibration was made, or nil if it wasn't measured
+	Zero *float64 `mapstructure:"zero"`
+	// Time is when the calibration was made
+	Time time.Time `mapstructure:"time"`
+}
+
+// Calibration returns the saved calibration
+func (c CalibrationConfig) Calibration() (loadcell.InvertibleCalibration, error) {
+	if len(c.Coefficients) > 0 && len(c.Spec.Polynomial) == 0 {
+		c.Spec.Polynomial = c.Coefficients
+	}
+	return c.Spec.Calibration()
+}
+
+// Load reads the config from viper
+func Load() (HangboardConfig, error) {
+	var c HangboardConfig
+	if err := viper.Unmarshal(&c); err != nil {
+		return HangboardConfig{}, err
+	}
+	return c, nil
+}
+
+// SaveCalibration stores c in the config file, creating the file in
+// the home directory if there isn't one yet
+func SaveCalibration(c CalibrationConfig) error {
+	calibration := map[string]interface{}{
+		"sample-rate": c.SampleRate,
+		"time":        c.Time,
+	}
+	if c.Zero != nil {
+		calibration["zero"] = *c.Zero
+	}
+	if c.Spec.Linear != nil {
+		calibration["linear"] = c.Spec.Linear
+	}
+	if c.Spec.Piecewise != nil {
+		calibration["piecewise"] = c.Spec.Piecewise
+	}
+	if len(c.Spec.Polynomial) > 0 {
+		calibration["polynomial"] = c.Spec.Polynomial
+	}
+	// viper.Set would merge the new calibration into the one read
+	// from the file, leaving a calibration of another form behind,
+	// so write a copy of the settings in which it is replaced
+	settings := viper.AllSettings()
+	settings[keyCalibration] = calibration
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return err
+	}
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		file = filepath.Join(home, defaultConfigFile)
+	}
+	if err := v.WriteConfigAs(file); err != nil {
+		return err
+	}
+	viper.SetConfigFile(file)
+	return viper.ReadInConfig()
+}
@@ -12,9 +12,12 @@ const (
 	flagDebug                   = "debug"
 	flagGain                    = "gain"
 	flagInstantaneousRead       = "instantaneous"
+	flagReplay                  = "replay"
 	flagReset                   = "reset"
 	flagSamples                 = "samples"
+	flagSimulate                = "simulate"
 	flagUsePeriphImplementation = "use-periph-implementation"
+	flagWaitForEdge             = "wait-for-edge"
 )
 
 func setupCmd() error {
@@ -27,11 +30,25 @@ func setupCmd() error {
 	if err := viper.BindPFlag(flagUsePeriphImplementation, readCmd.Flag(flagUsePeriphImplementation)); err != nil {
 		return err
 	}
+	readCmd.Flags().Bool(flagSimulate, false, "read from a simulated hx711 instead of hardware")
+	readCmd.Flag(flagSimulate).NoOptDefVal = "true"
+	if err := viper.BindPFlag(flagSimulate, readCmd.Flag(flagSimulate)); err != nil {
+		return err
+	}
+	readCmd.Flags().String(flagReplay, "", "read from a raw recording made by `hangboard workout --record-raw` instead of hardware")
+	if err := viper.BindPFlag(flagReplay, readCmd.Flag(flagReplay)); err != nil {
+		return err
+	}
 	readCmd.Flags().BoolP(flagReset, "r", false, "reset hardware on startup (only applies when --use-periph-implementation is false)")
 	readCmd.Flag(flagReset).NoOptDefVal = "true"
 	if err := viper.BindPFlag(flagReset, readCmd.Flag(flagReset)); err != nil {
 		return err
 	}
+	readCmd.Flags().BoolP(flagWaitForEdge, "e", false, "wait for data using edge detection instead of polling (only applies when --use-periph-implementation is false)")
+	readCmd.Flag(flagWaitForEdge).NoOptDefVal = "true"
+	if err := viper.BindPFlag(flagWaitForEdge, readCmd.Flag(flagWaitForEdge)); err != nil {
+		return err
+	}
 	readCmd.Flags().BoolP(flagContinuous, "c", false, "read values using continuous implementation")
 	readCmd.Flag(flagContinuous).NoOptDefVal = "true"
 	if err := viper.BindPFlag(flagContinuous, readCmd.Flag(flagContinuous)); err != nil {
@@ -59,6 +76,10 @@ func validateFlags(cmd *cobra.Command) {
 		cmd.PrintErrln("reset only applies when use-periph-implementation is unset, will ignore reset flag")
 		viper.Set(flagReset, false)
 	}
+	if viper.GetBool(flagUsePeriphImplementation) && viper.GetBool(flagSimulate) {
+		cmd.PrintErrln("periph implementation cannot be simulated, will ignore use-periph-implementation flag")
+		viper.Set(flagUsePeriphImplementation, false)
+	}
 	if viper.GetBool(flagInstantaneousRead) && viper.GetBool(flagContinuous) {
 		cmd.PrintErrln("instantaneous flag and continuous read modes are mutually exclusive, will ignore instantaneous flag")
 		viper.Set(flagInstantaneousRead, false)
@@ -5,12 +5,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/chewr/tension-scale/errutil"
 	"github.com/chewr/tension-scale/hx711"
 	"github.com/chewr/tension-scale/hx711/backcompat"
+	"github.com/chewr/tension-scale/hx711/replay"
+	"github.com/chewr/tension-scale/hx711/sim"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"periph.io/x/periph/experimental/conn/analog"
@@ -32,6 +35,28 @@ var (
 )
 
 func loadHx711(cmd *cobra.Command) (backcompat.HX711, error) {
+	if replayFile := viper.GetString(flagReplay); replayFile != "" {
+		cmd.Println("Replaying hx711 recording from", replayFile)
+		f, err := os.Open(replayFile)
+		if err != nil {
+			return nil, err
+		}
+		hxv2, err := replay.Open(f)
+		if err != nil {
+			return nil, err
+		}
+		return backcompat.HX711FromV2(hxv2), nil
+	}
+	if viper.GetBool(flagSimulate) {
+		cmd.Println("Using simulated hx711")
+		hxv2 := sim.New(
+			sim.RepeatedHang(500000, 5*time.Second, 5*time.Second),
+			sim.WithOffset(-31000),
+			sim.WithNoise(400),
+			sim.WithGlitches(0.01),
+		)
+		return backcompat.HX711FromV2(hxv2), nil
+	}
 	if _, err := host.Init(); err != nil {
 		return nil, err
 	}
@@ -42,14 +67,27 @@ func loadHx711(cmd *cobra.Command) (backcompat.HX711, error) {
 		return periphimpl.New(sclk, dout)
 	}
 	cmd.Println("Using custom hx711 driver implementation")
-	hxv2, err := hx711.New(sclk, dout)
+	var opts []hx711.Option
+	if viper.GetBool(flagWaitForEdge) {
+		opts = append(opts, hx711.WithEdgeDetection())
+	}
+	hxv2, err := hx711.New(sclk, dout, opts...)
 	if err != nil {
 		return nil, err
 	}
+	if r, ok := hxv2.(hx711.WaitModeReporter); ok {
+		cmd.Println("Waiting for data by", r.WaitMode())
+	}
 	if viper.GetBool(flagReset) {
 		cmd.Println("Resetting the hx711 module")
 		err = hxv2.Reset(cmd.Context())
 	}
+	if detector, ok := hxv2.(hx711.SampleRateDetector); ok && err == nil {
+		if _, err = detector.DetectSampleRate(cmd.Context()); err == nil {
+			rate, interval, _ := detector.SampleRate()
+			cmd.Println(fmt.Sprintf("Detected sample rate of %dSPS (measured interval %s)", rate, interval))
+		}
+	}
 	hx := backcompat.HX711FromV2(hxv2)
 	return hx, err
 }
@@ -85,7 +123,13 @@ func doRead(cmd *cobra.Command, args []string) error {
 	sp.Start(ctx)
 	defer errutil.SwallowF(func() error { return sp.Stop() })
 
-	return consume(ctx, cmd, sp)
+	err = consume(ctx, cmd, sp)
+	if p, ok := sp.(*continuousSampleProducer); ok {
+		if missed, dropped, ok := p.Lost(); ok {
+			cmd.Println(fmt.Sprintf("Missed %d samples, of which %d were dropped while reading fell behind", missed, dropped))
+		}
+	}
+	return err
 }
 
 func getSampleProducer(cmd *cobra.Command) (SampleProducer, error) {
@@ -150,6 +194,24 @@ func (p *continuousSampleProducer) Start(ctx context.Context) {
 	}()
 }
 
+// lossReporter is implemented by devices which count the samples
+// lost from ReadContinuous
+type lossReporter interface {
+	Missed() uint64
+	Dropped() uint64
+}
+
+// Lost returns the number of samples missed by the device and the
+// number of those which were dropped because they were not read in
+// time, if the device counts them
+func (p *continuousSampleProducer) Lost() (missed, dropped uint64, ok bool) {
+	r, ok := p.hx.(lossReporter)
+	if !ok {
+		return 0, 0, false
+	}
+	return r.Missed(), r.Dropped(), true
+}
+
 func (p *continuousSampleProducer) Stop() error {
 	err := p.hx.Halt()
 	p.Mutex.Lock()
@@ -14,6 +14,8 @@ const (
 	Work
 	Tare
 	Wait
+	Unload
+	Paused
 )
 
 func (t WorkoutStateType) String() string {
@@ -28,6 +30,10 @@ func (t WorkoutStateType) String() string {
 		return "Taring"
 	case Wait:
 		return "Ready"
+	case Unload:
+		return "Unload the board"
+	case Paused:
+		return "Paused"
 	default:
 		return "Unknown"
 	}
@@ -55,6 +61,7 @@ type State interface {
 	// TODO(rchew) better to just do this with casting?
 	ExpiringState() (ExpiringState, bool)
 	InputDependentState() (InputDependentState, bool)
+	PausedState() (PausedState, bool)
 }
 
 type InputDependentState interface {
@@ -71,6 +78,13 @@ type ExpiringState interface {
 	Fallback() State
 }
 
+// PausedState is a state whose countdown is frozen while the workout
+// is paused
+type PausedState interface {
+	AbstractState
+	Remaining() time.Duration
+}
+
 type UserInput interface {
 	GetValue() UserInputValue
 }
@@ -48,7 +48,7 @@ func NewCliDisplay(w io.Writer) (display.AutoRefreshingModel, error) {
 
 func title(state display.State) refresh.CliOutput {
 	switch state.GetType() {
-	case display.Work, display.Rest, display.Tare, display.Wait:
+	case display.Work, display.Rest, display.Tare, display.Wait, display.Unload, display.Paused:
 		return refresh.FromString(fmt.Sprint(state.GetType()))
 	default:
 		return refresh.NoShow()
@@ -56,6 +56,12 @@ func title(state display.State) refresh.CliOutput {
 }
 
 func clock(state display.State) refresh.CliOutput {
+	if paused, ok := state.PausedState(); ok {
+		if paused.Remaining() <= 0 {
+			return refresh.NoShow()
+		}
+		return refresh.FromString(fmt.Sprintf("%5.2fs", paused.Remaining().Seconds()))
+	}
 	if expiring, ok := state.ExpiringState(); ok {
 		ttl := expiring.Deadline().Sub(time.Now())
 		return refresh.FromString(fmt.Sprintf("%5.2fs", ttl.Seconds()))
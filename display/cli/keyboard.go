@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/chewr/tension-scale/isometric/control"
+)
+
+// KeyboardHelp describes the keys read by KeyboardControls
+const KeyboardHelp = "enter: pause/resume, s+enter: skip interval, r+enter: repeat interval"
+
+// KeyboardControls drives c from lines typed at r until ctx is done or
+// r is exhausted: an empty line or p pauses and resumes, s skips the
+// current interval and r repeats it. The terminal is left in line
+// mode, so each key must be followed by enter.
+func KeyboardControls(ctx context.Context, r io.Reader, c *control.Controls) {
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if ctx.Err() != nil {
+				return
+			}
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "", "p":
+				c.TogglePause()
+			case "s":
+				c.Skip()
+			case "r":
+				c.Repeat()
+			}
+		}
+	}()
+}
@@ -41,13 +41,14 @@ func (input *DynamicEdgeInput) Update(samples ...loadcell.ForceSample) {
 	input.mu.Lock()
 	defer input.mu.Unlock()
 	for _, s := range samples {
-		input.rising = s.Force >= input.prevForce
+		f := s.Filtered()
+		input.rising = f >= input.prevForce
 		if !input.rising {
-			input.startForce = s.Force
-		} else if s.Force-input.startForce > input.maxRisingEdge {
-			input.maxRisingEdge = s.Force - input.startForce
+			input.startForce = f
+		} else if f-input.startForce > input.maxRisingEdge {
+			input.maxRisingEdge = f - input.startForce
 		}
-		input.prevForce = s.Force
+		input.prevForce = f
 	}
 }
 
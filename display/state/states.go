@@ -25,6 +25,17 @@ func Tare(deadline time.Time) display.State {
 	return display.NewState(display.Tare, display.WithExpiryAndFallback(deadline, Halt()))
 }
 
+// Unload asks the user to get off the board so that it can be tared
+func Unload() display.State {
+	return display.NewState(display.Unload)
+}
+
+// Paused shows that the workout is paused with remaining time left in
+// the current interval
+func Paused(remaining time.Duration) display.State {
+	return display.NewState(display.Paused, display.WithFrozenClock(remaining))
+}
+
 func Rest(deadline time.Time) display.State {
 	// TODO(rchew) better to fall back to original state rather than halt?
 	return display.NewState(display.Rest, display.WithExpiryAndFallback(deadline, Halt()))
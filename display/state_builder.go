@@ -31,13 +31,21 @@ func WithExpectedUserInput(expected ExpectedInput, actual ActualInput) StateBuil
 	})
 }
 
+// WithFrozenClock shows a countdown stopped with remaining time left
+func WithFrozenClock(remaining time.Duration) StateBuilderOption {
+	return sbOptFn(func(builder *stateImpl) {
+		builder.remaining = remaining
+		builder.isPaused = true
+	})
+}
+
 type stateImpl struct {
 	// TODO(rchew) finagle a way to get this to be immutable through options
 	// ^ not entirely necessary as options are not user-implementable
 	stateType WorkoutStateType
 
 	baseAbstractState
-	isExpiring, isInputDependent bool
+	isExpiring, isInputDependent, isPaused bool
 
 	deadline time.Time
 	fallback State
@@ -45,6 +53,8 @@ type stateImpl struct {
 	expected ExpectedInput
 	actual   ActualInput
 
+	remaining time.Duration
+
 	mutableState *mutableStateImpl
 }
 
@@ -112,6 +122,17 @@ func (s *stateImpl) ExpiringState() (ExpiringState, bool) {
 	return nil, false
 }
 
+func (s *stateImpl) PausedState() (PausedState, bool) {
+	if s.isPaused {
+		return s, true
+	}
+	return nil, false
+}
+
+func (s *stateImpl) Remaining() time.Duration {
+	return s.remaining
+}
+
 func (s *stateImpl) Deadline() time.Time {
 	return s.deadline
 }
@@ -3,6 +3,7 @@ package hx711
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/chewr/tension-scale/measurement"
 )
@@ -15,6 +16,105 @@ const (
 	ChannelB32  Gain = 2
 )
 
+func (g Gain) String() string {
+	switch g {
+	case ChannelA128:
+		return "A128"
+	case ChannelA64:
+		return "A64"
+	case ChannelB32:
+		return "B32"
+	default:
+		return "unknown"
+	}
+}
+
+// Amplification returns the factor by which g amplifies the load
+// cell's signal, or 0 if g is unknown
+func (g Gain) Amplification() int {
+	switch g {
+	case ChannelA128:
+		return 128
+	case ChannelA64:
+		return 64
+	case ChannelB32:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// SampleRate is the output data rate of the HX711, which is
+// selected in hardware by the RATE pin
+type SampleRate int
+
+const (
+	Rate10SPS SampleRate = 10
+	Rate80SPS SampleRate = 80
+)
+
+// Interval returns the time between conversions at the sample rate
+func (r SampleRate) Interval() time.Duration {
+	return time.Second / time.Duration(r)
+}
+
+// NearestSampleRate returns the sample rate whose conversion interval
+// is closest to the measured interval
+func NearestSampleRate(interval time.Duration) SampleRate {
+	// split the difference geometrically, since the rates are
+	// nearly an order of magnitude apart
+	slow, fast := Rate10SPS.Interval(), Rate80SPS.Interval()
+	if float64(interval)*float64(interval) > float64(slow)*float64(fast) {
+		return Rate10SPS
+	}
+	return Rate80SPS
+}
+
+// WaitMode describes how a driver waits for a conversion to be ready
+type WaitMode int
+
+const (
+	// WaitPolling busy-polls the DOUT pin
+	WaitPolling WaitMode = iota
+	// WaitEdge blocks on a falling edge of the DOUT pin
+	WaitEdge
+)
+
+func (m WaitMode) String() string {
+	switch m {
+	case WaitPolling:
+		return "polling"
+	case WaitEdge:
+		return "edge"
+	default:
+		return "unknown"
+	}
+}
+
+// WaitModeReporter is implemented by drivers which can report how
+// they wait for conversions
+type WaitModeReporter interface {
+	WaitMode() WaitMode
+}
+
+// SampleRateDetector is implemented by drivers which can determine
+// the sample rate selected by the RATE pin
+type SampleRateDetector interface {
+	// DetectSampleRate times consecutive conversions to determine
+	// the sample rate of the device
+	DetectSampleRate(ctx context.Context) (SampleRate, error)
+	// SampleRate returns the most recently detected sample rate and
+	// the measured conversion interval, or false if the rate has
+	// not been detected
+	SampleRate() (SampleRate, time.Duration, bool)
+}
+
+// GainReporter is implemented by drivers which can report the gain,
+// and so channel, of their conversions
+type GainReporter interface {
+	Gain() Gain
+}
+
 var (
 	ErrGainUnavailable = errors.New("specified gain value is unavailable")
 )
@@ -5,17 +5,20 @@ import (
 	"time"
 
 	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/measurement"
 	"periph.io/x/periph/conn/pin"
 	"periph.io/x/periph/experimental/conn/analog"
 	periphimpl "periph.io/x/periph/experimental/devices/hx711"
 )
 
 func HX711FromV2(v2 hx711.V2) HX711 {
-	return &hx711Bridge{v2}
+	return &hx711Bridge{v2: v2}
 }
 
 type hx711Bridge struct {
-	v2 hx711.V2
+	v2    hx711.V2
+	drops measurement.DropCounter
+	gaps  measurement.GapDetector
 }
 
 func (b *hx711Bridge) ReadTimeout(timeout time.Duration) (int32, error) {
@@ -61,14 +64,25 @@ func (b *hx711Bridge) Func() pin.Func {
 
 func (b *hx711Bridge) String() string { return b.v2.String() }
 func (b *hx711Bridge) ReadContinuous() <-chan analog.Sample {
-	ch := b.v2.ReadContinuous()
+	ch := b.v2.ReadContinuous(context.TODO(), measurement.WithDropCounter(&b.drops))
 	out := make(chan analog.Sample)
 	go func() {
 		defer close(out)
 		for r := range ch {
+			b.gaps.Observe(r)
 			out <- r.Sample
 		}
 	}()
 	return out
 }
+
+// Missed returns the number of conversions missing from
+// ReadContinuous, according to the samples' sequence numbers
+func (b *hx711Bridge) Missed() uint64 { return b.gaps.Missed() }
+
+// Dropped returns the number of samples ReadContinuous dropped
+// because they were not received in time. Dropped samples are also
+// counted as missed.
+func (b *hx711Bridge) Dropped() uint64 { return b.drops.Dropped() }
+
 func (b *hx711Bridge) Halt() error { return b.v2.Halt() }
@@ -33,35 +33,37 @@ func (v *v2Bridge) Reset(ctx context.Context) error {
 
 // ReadContinuous implements hx711.V2
 //
-// This implementation prefers fresh data from the underlying
-// HX711. If new data becomes available while it is blocking on
-// sending old data, it will discard the old data. This is
-// because the timestamp must be appended to the data as soon
-// as possible after the data has been read
-func (v *v2Bridge) ReadContinuous() <-chan measurement.TimeSeriesSample {
+// Unless otherwise specified by opts, this implementation prefers
+// fresh data from the underlying HX711. If new data becomes
+// available while it is blocking on sending old data, it will
+// discard the old data. This is because the timestamp must be
+// appended to the data as soon as possible after the data has
+// been read
+func (v *v2Bridge) ReadContinuous(ctx context.Context, opts ...measurement.StreamOption) <-chan measurement.TimeSeriesSample {
 	ch := v.hx.ReadContinuous()
-	ret := make(chan measurement.TimeSeriesSample)
+	opts = append([]measurement.StreamOption{
+		measurement.WithBufferSize(1),
+		measurement.WithOverflowPolicy(measurement.DropOldest),
+	}, opts...)
+	s := measurement.NewSampleStream(ctx, opts...)
 	go func() {
-		defer close(ret)
-		var (
-			in       analog.Sample
-			out      measurement.TimeSeriesSample
-			chanOpen bool
-		)
-		in, chanOpen = <-ch
-		for chanOpen {
-			out = measurement.TimeSeriesSample{
-				Sample: in,
-				Time:   time.Now(),
-			}
+		defer s.Close()
+		for {
 			select {
-			case in, chanOpen = <-ch:
-			case ret <- out:
-				in, chanOpen = <-ch
+			case <-ctx.Done():
+				return
+			case in, ok := <-ch:
+				if !ok {
+					return
+				}
+				s.Push(measurement.TimeSeriesSample{
+					Sample: in,
+					Time:   time.Now(),
+				})
 			}
 		}
 	}()
-	return ret
+	return s.C()
 }
 
 func (v *v2Bridge) Halt() error { return v.hx.Halt() }
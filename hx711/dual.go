@@ -0,0 +1,373 @@
+package hx711
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chewr/tension-scale/measurement"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/experimental/conn/analog"
+)
+
+// NewDualChannel creates a HX711 which alternates conversions between
+// channel A at gain 128 and channel B at gain 32, for boards with a
+// load cell on each channel. The first conversion after each switch
+// has not settled and is discarded, so each channel is sampled at a
+// quarter of the device's sample rate.
+//
+// Each of the returned sensors reads only its own channel, at that
+// channel's fixed gain. The device is powered down once both have
+// been halted.
+func NewDualChannel(clk gpio.PinOut, data gpio.PinIn, opts ...Option) (a, b V2, err error) {
+	hx, err := New(clk, data, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := &dualChannel{
+		dev: hx.(*dev),
+	}
+	d.channels = [2]*channelSensor{
+		{dual: d, gain: ChannelA128, name: d.dev.name + "/A128"},
+		{dual: d, gain: ChannelB32, name: d.dev.name + "/B32"},
+	}
+	d.start()
+	return d.channels[0], d.channels[1], nil
+}
+
+type dualChannel struct {
+	// Immutable.
+	dev      *dev
+	channels [2]*channelSensor
+
+	// Mutable.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// start begins interleaving conversions if not already running
+func (d *dualChannel) start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.startLocked()
+}
+
+func (d *dualChannel) startLocked() {
+	if d.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	for _, c := range d.channels {
+		c.fail(nil)
+	}
+	go d.run(ctx)
+}
+
+// reset powers the device back up if it has been halted and resumes
+// interleaving. The device is left alone while interleaving is in
+// progress, as resetting it would upset the choice of channel.
+func (d *dualChannel) reset(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return nil
+	}
+	if err := d.dev.Reset(ctx); err != nil {
+		return err
+	}
+	d.startLocked()
+	return nil
+}
+
+// stop halts the device once every channel has been halted
+func (d *dualChannel) stop() error {
+	for _, c := range d.channels {
+		if !c.isHalted() {
+			return nil
+		}
+	}
+	d.mu.Lock()
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+	d.mu.Unlock()
+	return d.dev.Halt()
+}
+
+func (d *dualChannel) other(g Gain) *channelSensor {
+	if g == d.channels[0].gain {
+		return d.channels[1]
+	}
+	return d.channels[0]
+}
+
+func (d *dualChannel) channel(g Gain) *channelSensor {
+	if g == d.channels[0].gain {
+		return d.channels[0]
+	}
+	return d.channels[1]
+}
+
+func (d *dualChannel) run(ctx context.Context) {
+	err := d.interleave(ctx)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if ctx.Err() != nil {
+		// stopped by stop, which has already cleared cancel
+		return
+	}
+	// let reset start interleaving again, and wake any readers
+	// waiting for a sample which will never come
+	d.cancel()
+	d.cancel = nil
+	for _, c := range d.channels {
+		c.fail(err)
+	}
+}
+
+// interleave alternates conversions between the channels until ctx
+// is done or the device fails
+func (d *dualChannel) interleave(ctx context.Context) error {
+	// pending is the channel of the conversion in progress, which
+	// has settled if the previous conversion was on the same channel
+	pending := d.channels[0].gain
+	settled := false
+	if err := d.dev.SetGain(ctx, pending); err != nil && err != ErrBadRead {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if !settled {
+			// discard the settling conversion, staying on the
+			// same channel for the next one
+			if _, err := d.dev.readSelectingNext(ctx, pending); err != nil && err != ErrBadRead {
+				return err
+			}
+			settled = true
+			continue
+		}
+		next := d.other(pending)
+		ts, err := d.dev.readSelectingNext(ctx, next.gain)
+		switch err {
+		case nil, ErrBadRead:
+			d.channel(pending).deliver(ts, err)
+		default:
+			return err
+		}
+		pending, settled = next.gain, false
+	}
+}
+
+// readSelectingNext reads the pending conversion and selects the
+// gain, and therefore channel, of the conversion after it
+func (d *dev) readSelectingNext(ctx context.Context, next Gain) (measurement.TimeSeriesSample, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inputMode = next
+	return d.blockingRead(ctx)
+}
+
+// channelSensor reads one channel of a dual channel HX711
+type channelSensor struct {
+	// Immutable.
+	dual *dualChannel
+	gain Gain
+	name string
+
+	// Mutable.
+	mu     sync.Mutex
+	seq    uint64
+	halted bool
+	// err is why interleaving stopped, until it is restarted
+	err error
+	// latest is the most recent sample, which is unread if fresh
+	latest measurement.TimeSeriesSample
+	fresh  bool
+	stream *measurement.SampleStream
+	// waiters are woken when a sample arrives
+	waiters []chan struct{}
+}
+
+func (c *channelSensor) deliver(ts measurement.TimeSeriesSample, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// bad reads still use up a sequence number so that they show
+	// up as gaps
+	c.seq++
+	if err != nil || c.halted {
+		return
+	}
+	ts.Seq = c.seq
+	c.latest, c.fresh = ts, true
+	if c.stream != nil {
+		c.stream.Push(ts)
+	}
+	c.wakeLocked()
+}
+
+// wakeLocked wakes any waiting readers. It must be called with c.mu
+// held.
+func (c *channelSensor) wakeLocked() {
+	for _, w := range c.waiters {
+		close(w)
+	}
+	c.waiters = nil
+}
+
+// closeStreamLocked ends any stream in progress. It must be called
+// with c.mu held.
+func (c *channelSensor) closeStreamLocked() {
+	if c.stream != nil {
+		c.stream.Close()
+		c.stream = nil
+	}
+}
+
+// fail records why interleaving stopped, waking any waiting readers
+// and ending any stream, or clears the failure once interleaving
+// restarts
+func (c *channelSensor) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+	if err == nil {
+		return
+	}
+	c.closeStreamLocked()
+	c.wakeLocked()
+}
+
+func (c *channelSensor) isHalted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.halted
+}
+
+// String implements conn.Resource
+func (c *channelSensor) String() string { return c.name }
+
+// Halt implements conn.Resource
+func (c *channelSensor) Halt() error {
+	c.mu.Lock()
+	c.halted = true
+	c.closeStreamLocked()
+	c.wakeLocked()
+	c.mu.Unlock()
+	return c.dual.stop()
+}
+
+// Reset implements measurement.Sensor
+func (c *channelSensor) Reset(ctx context.Context) error {
+	c.mu.Lock()
+	c.halted = false
+	c.mu.Unlock()
+	if err := c.dual.reset(ctx); err != nil {
+		return err
+	}
+	_, err := c.waitForSample(ctx, false)
+	return err
+}
+
+// IsReady implements measurement.Sensor
+func (c *channelSensor) IsReady() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.halted && c.fresh
+}
+
+// Read implements measurement.Sensor
+func (c *channelSensor) Read(ctx context.Context) (measurement.TimeSeriesSample, error) {
+	return c.waitForSample(ctx, true)
+}
+
+func (c *channelSensor) waitForSample(ctx context.Context, consume bool) (measurement.TimeSeriesSample, error) {
+	for {
+		c.mu.Lock()
+		if c.halted {
+			c.mu.Unlock()
+			return measurement.TimeSeriesSample{}, ErrStopped
+		}
+		if c.err != nil {
+			err := c.err
+			c.mu.Unlock()
+			return measurement.TimeSeriesSample{}, err
+		}
+		if c.fresh {
+			ts := c.latest
+			if consume {
+				c.fresh = false
+			}
+			c.mu.Unlock()
+			return ts, nil
+		}
+		w := make(chan struct{})
+		c.waiters = append(c.waiters, w)
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return measurement.TimeSeriesSample{}, ctx.Err()
+		case <-w:
+		}
+	}
+}
+
+// TryRead implements measurement.Sensor
+func (c *channelSensor) TryRead() (measurement.TimeSeriesSample, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.halted {
+		return measurement.TimeSeriesSample{}, ErrStopped
+	}
+	if !c.fresh {
+		if c.err != nil {
+			return measurement.TimeSeriesSample{}, c.err
+		}
+		return measurement.TimeSeriesSample{}, ErrNotReady
+	}
+	c.fresh = false
+	return c.latest, nil
+}
+
+// ReadContinuous implements measurement.StreamingSensor
+func (c *channelSensor) ReadContinuous(ctx context.Context, opts ...measurement.StreamOption) <-chan measurement.TimeSeriesSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream != nil || c.halted || c.err != nil {
+		// read already in progress, or no samples to come
+		return nil
+	}
+	s := measurement.NewSampleStream(ctx, opts...)
+	c.stream = s
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.stream == s {
+			c.stream = nil
+		}
+	}()
+	return s.C()
+}
+
+// SetGain implements V2. Each channel has a fixed gain, so no other
+// gain is available.
+func (c *channelSensor) SetGain(_ context.Context, g Gain) error {
+	if g != c.gain {
+		return ErrGainUnavailable
+	}
+	return nil
+}
+
+// Gain implements GainReporter
+func (c *channelSensor) Gain() Gain { return c.gain }
+
+// Range implements measurement.Sensor
+func (c *channelSensor) Range() (analog.Sample, analog.Sample) {
+	return c.dual.dev.Range()
+}
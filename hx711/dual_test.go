@@ -0,0 +1,265 @@
+package hx711_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/hx711/hx711test"
+	"periph.io/x/periph/conn/gpio"
+)
+
+// dualSource numbers each conversion and tags it with its channel,
+// as 10·n+1 on channel A and 10·n+2 on channel B
+func dualSource(n int, gain hx711.Gain) int32 {
+	if gain == hx711.ChannelB32 {
+		return int32(10*n + 2)
+	}
+	return int32(10*n + 1)
+}
+
+type dualScenario func(ctx context.Context, a, b hx711.V2, chip *hx711test.Chip) error
+
+func runDual(t *testing.T, s dualScenario) {
+	t.Helper()
+	runChip(t, dualSource, func(ctx context.Context, chip *hx711test.Chip) error {
+		return dual(ctx, chip.Clock(), chip, s)
+	})
+}
+
+func dual(ctx context.Context, clk gpio.PinOut, chip *hx711test.Chip, s dualScenario) error {
+	a, b, err := hx711.NewDualChannel(clk, chip.Data())
+	if err != nil {
+		return fmt.Errorf("NewDualChannel: %v", err)
+	}
+	defer b.Halt()
+	defer a.Halt()
+	return s(ctx, a, b, chip)
+}
+
+// readChannel reads n samples, checking that they are from the
+// channel tagged tag and that no conversion on the channel is
+// delivered twice
+func readChannel(ctx context.Context, c hx711.V2, tag int32, n int) error {
+	var lastConversion int32 = -1
+	var lastSeq uint64
+	for i := 0; i < n; i++ {
+		ts, err := c.Read(ctx)
+		if err != nil {
+			return fmt.Errorf("%v: Read: %v", c, err)
+		}
+		if ts.Raw%10 != tag {
+			return fmt.Errorf("%v: read %d from the other channel", c, ts.Raw)
+		}
+		conversion := ts.Raw / 10
+		// each reading follows a settling conversion, so there
+		// are four conversions per reading of each channel
+		if conversion <= lastConversion || (lastConversion >= 0 && (conversion-lastConversion)%4 != 0) {
+			return fmt.Errorf("%v: read conversion %d after %d", c, conversion, lastConversion)
+		}
+		if ts.Seq <= lastSeq {
+			return fmt.Errorf("%v: sequence number %d did not increase from %d", c, ts.Seq, lastSeq)
+		}
+		lastConversion, lastSeq = conversion, ts.Seq
+	}
+	return nil
+}
+
+func TestDualChannelInterleaves(t *testing.T) {
+	runDual(t, func(ctx context.Context, a, b hx711.V2, _ *hx711test.Chip) error {
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, c := range []hx711.V2{a, b} {
+			wg.Add(1)
+			go func(i int, c hx711.V2) {
+				defer wg.Done()
+				errs[i] = readChannel(ctx, c, int32(i+1), 4)
+			}(i, c)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func TestDualChannelGain(t *testing.T) {
+	runDual(t, func(ctx context.Context, a, b hx711.V2, _ *hx711test.Chip) error {
+		for _, tc := range []struct {
+			c    hx711.V2
+			gain hx711.Gain
+		}{
+			{a, hx711.ChannelA128},
+			{b, hx711.ChannelB32},
+		} {
+			if g, ok := tc.c.(hx711.GainReporter); !ok || g.Gain() != tc.gain {
+				return fmt.Errorf("%v does not report gain %v", tc.c, tc.gain)
+			}
+			if err := tc.c.SetGain(ctx, tc.gain); err != nil {
+				return fmt.Errorf("%v: SetGain(%v): %v", tc.c, tc.gain, err)
+			}
+			if err := tc.c.SetGain(ctx, hx711.ChannelA64); err != hx711.ErrGainUnavailable {
+				return fmt.Errorf("%v: SetGain(%v) = %v, want %v", tc.c, hx711.ChannelA64, err, hx711.ErrGainUnavailable)
+			}
+		}
+		return nil
+	})
+}
+
+func TestDualChannelHaltReset(t *testing.T) {
+	runDual(t, func(ctx context.Context, a, b hx711.V2, chip *hx711test.Chip) error {
+		if err := a.Halt(); err != nil {
+			return fmt.Errorf("Halt: %v", err)
+		}
+		if _, err := a.Read(ctx); err != hx711.ErrStopped {
+			return fmt.Errorf("read %v while halted: got %v, want %v", a, err, hx711.ErrStopped)
+		}
+		// the other channel carries on
+		if err := readChannel(ctx, b, 2, 2); err != nil {
+			return err
+		}
+		if !chip.Stats().PoweredOn {
+			return fmt.Errorf("powered down with %v still in use", b)
+		}
+
+		if err := b.Halt(); err != nil {
+			return fmt.Errorf("Halt: %v", err)
+		}
+		if s := chip.Stats(); s.PoweredOn {
+			return fmt.Errorf("still powered on after halting both channels")
+		}
+
+		if err := a.Reset(ctx); err != nil {
+			return fmt.Errorf("Reset: %v", err)
+		}
+		if s := chip.Stats(); !s.PoweredOn || s.PowerCycles != 1 {
+			return fmt.Errorf("got %d power cycles after Reset, want 1", s.PowerCycles)
+		}
+		if err := readChannel(ctx, a, 1, 2); err != nil {
+			return err
+		}
+		if err := b.Reset(ctx); err != nil {
+			return fmt.Errorf("Reset: %v", err)
+		}
+		return readChannel(ctx, b, 2, 2)
+	})
+}
+
+func TestDualChannelHaltWhileReading(t *testing.T) {
+	runDual(t, func(ctx context.Context, a, b hx711.V2, _ *hx711test.Chip) error {
+		if err := readChannel(ctx, a, 1, 1); err != nil {
+			return err
+		}
+		reading := make(chan struct{})
+		errs := make(chan error, 1)
+		go func() {
+			close(reading)
+			_, err := a.Read(ctx)
+			errs <- err
+		}()
+		// let the reader block waiting for the next sample, which is
+		// four conversions away
+		<-reading
+		time.Sleep(5 * time.Millisecond)
+		if err := a.Halt(); err != nil {
+			return fmt.Errorf("Halt: %v", err)
+		}
+		// the other channel keeps the device interleaving, so no
+		// sample will wake the reader
+		select {
+		case err := <-errs:
+			if err != nil && err != hx711.ErrStopped {
+				return fmt.Errorf("read %v while halting: got %v, want %v", a, err, hx711.ErrStopped)
+			}
+		case <-time.After(time.Second):
+			return fmt.Errorf("read %v still blocked after Halt", a)
+		}
+		return readChannel(ctx, b, 2, 2)
+	})
+}
+
+var errClockFault = errors.New("clock pin fault")
+
+// faultyClock fails to raise PD_SCK at the start of reads once
+// told to, leaving reads already in progress to finish
+type faultyClock struct {
+	gpio.PinIO
+
+	mu      sync.Mutex
+	failing bool
+	last    time.Time
+}
+
+func (p *faultyClock) setFailing(failing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failing = failing
+}
+
+func (p *faultyClock) Out(l gpio.Level) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	// pulses within a read are microseconds apart
+	starting := now.Sub(p.last) > time.Millisecond
+	if p.failing && l == gpio.High && starting {
+		return errClockFault
+	}
+	p.last = now
+	return p.PinIO.Out(l)
+}
+
+func TestDualChannelRecovers(t *testing.T) {
+	runChip(t, dualSource, func(ctx context.Context, chip *hx711test.Chip) error {
+		clk := &faultyClock{PinIO: chip.Clock()}
+		return dual(ctx, clk, chip, func(ctx context.Context, a, b hx711.V2, _ *hx711test.Chip) error {
+			if err := readChannel(ctx, a, 1, 1); err != nil {
+				return err
+			}
+			streamCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			stream := b.ReadContinuous(streamCtx)
+			if stream == nil {
+				return fmt.Errorf("%v: ReadContinuous returned no stream", b)
+			}
+
+			clk.setFailing(true)
+			// readers waiting for the next sample learn that
+			// there won't be one
+			for {
+				_, err := a.Read(ctx)
+				if err == errClockFault {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("read %v from failed device: got %v, want %v", a, err, errClockFault)
+				}
+			}
+			// and streams end
+			for closed := false; !closed; {
+				select {
+				case _, ok := <-stream:
+					closed = !ok
+				case <-time.After(time.Second):
+					return fmt.Errorf("%v: stream still open after the device failed", b)
+				}
+			}
+
+			clk.setFailing(false)
+			if err := a.Reset(ctx); err != nil {
+				return fmt.Errorf("Reset: %v", err)
+			}
+			if err := readChannel(ctx, a, 1, 2); err != nil {
+				return err
+			}
+			return readChannel(ctx, b, 2, 2)
+		})
+	})
+}
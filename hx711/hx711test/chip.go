@@ -0,0 +1,396 @@
+// Package hx711test implements an in-memory emulation of the HX711
+// chip behind a pair of gpio pins, for exercising the bit-banging
+// protocol in hx711 without hardware
+package hx711test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	// timing spec from hx711 datasheet:
+	// https://components101.com/sites/default/files/component_datasheet/HX711%20Datasheet.pdf
+
+	maxPulseHigh  = 50 * time.Microsecond // T_3 maximum pd_sck high time
+	powerDownTime = 60 * time.Microsecond // time pd_sck must be held HIGH to power down
+
+	dataBits = 24
+	// total pulses for each gain setting, per the datasheet
+	pulsesA128 = dataBits + int(hx711.ChannelA128)
+	pulsesA64  = dataBits + int(hx711.ChannelA64)
+)
+
+var (
+	// ErrClockedWhileNotReady is recorded when PD_SCK is pulsed
+	// before DOUT has signalled that a conversion is ready
+	ErrClockedWhileNotReady = errors.New("pd_sck pulsed while data not ready")
+	// ErrPulseTooLong is recorded when PD_SCK is held high for
+	// longer than a data pulse but not long enough to power down
+	ErrPulseTooLong = errors.New("pd_sck held high longer than 50µs")
+	// ErrTooFewPulses is recorded when a read ends with fewer than
+	// the 25 pulses needed to select the next gain
+	ErrTooFewPulses = errors.New("fewer than 25 pd_sck pulses in read")
+	// ErrTooManyPulses is recorded when more than 27 pulses are
+	// sent in a read
+	ErrTooManyPulses = errors.New("more than 27 pd_sck pulses in read")
+	// ErrReadOverrun is recorded when a conversion completes while
+	// the previous one is still being shifted out
+	ErrReadOverrun = errors.New("conversion completed during read")
+
+	errNotInput  = errors.New("hx711test: PD_SCK is an output")
+	errNotOutput = errors.New("hx711test: DOUT is an input")
+	errNoPWM     = errors.New("hx711test: PWM is not supported")
+)
+
+// Source produces the raw value of the n-th conversion since the
+// chip was created, at the given gain
+type Source func(n int, gain hx711.Gain) int32
+
+// Values returns a Source which cycles through vs
+func Values(vs ...int32) Source {
+	return func(n int, _ hx711.Gain) int32 {
+		if len(vs) == 0 {
+			return 0
+		}
+		return vs[n%len(vs)]
+	}
+}
+
+// Violation is a breach of the HX711 protocol by the driver
+type Violation struct {
+	Time time.Time
+	Err  error
+}
+
+// Stats summarises what the chip has observed
+type Stats struct {
+	// Pulses is the total number of PD_SCK pulses
+	Pulses int
+	// Reads is the number of completed reads
+	Reads int
+	// LastReadPulses is the number of pulses in the most recent
+	// completed read
+	LastReadPulses int
+	// Gain is the gain used for the current conversion
+	Gain hx711.Gain
+	// PowerCycles counts power down/power up cycles
+	PowerCycles int
+	// PoweredOn reports whether the chip is currently powered on
+	PoweredOn  bool
+	Violations []Violation
+}
+
+// Chip emulates an HX711
+type Chip struct {
+	// Immutable.
+	source   Source
+	interval time.Duration
+
+	// Mutable.
+	mu          sync.Mutex
+	powerOn     bool
+	gain        hx711.Gain
+	nextGain    hx711.Gain
+	conversions int
+	next        time.Time
+	ready       bool
+	value       uint32
+	pulses      int
+	clkHigh     bool
+	clkRose     time.Time
+	stray       error
+	dout        gpio.Level
+	edge        gpio.Edge
+	pull        gpio.Pull
+	pendingEdge bool
+	stats       Stats
+}
+
+// NewChip returns an emulated HX711 which produces conversions from
+// source at the given sample rate, starting one interval from now
+func NewChip(source Source, rate hx711.SampleRate) *Chip {
+	c := &Chip{
+		source:   source,
+		interval: rate.Interval(),
+		powerOn:  true,
+		gain:     hx711.ChannelA128,
+		nextGain: hx711.ChannelA128,
+		next:     time.Now().Add(rate.Interval()),
+		dout:     gpio.High,
+	}
+	return c
+}
+
+// Clock returns the PD_SCK pin, which the driver drives
+func (c *Chip) Clock() gpio.PinIO {
+	return &clockPin{c}
+}
+
+// Data returns the DOUT pin, which the driver reads
+func (c *Chip) Data() gpio.PinIO {
+	return &dataPin{c}
+}
+
+// Stats returns a snapshot of what the chip has observed
+func (c *Chip) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance(time.Now())
+	s := c.stats
+	s.Gain = c.gain
+	s.PoweredOn = c.powerOn
+	s.Violations = append([]Violation(nil), c.stats.Violations...)
+	return s
+}
+
+func (c *Chip) violation(now time.Time, err error) {
+	c.stats.Violations = append(c.stats.Violations, Violation{Time: now, Err: err})
+}
+
+// advance brings the chip's state up to date with the clock
+func (c *Chip) advance(now time.Time) {
+	if c.powerOn && c.clkHigh && now.Sub(c.clkRose) >= powerDownTime {
+		c.powerOn = false
+		c.ready = false
+		c.pulses = 0
+	}
+	for c.powerOn && !now.Before(c.next) {
+		c.convert(c.next)
+		c.next = c.next.Add(c.interval)
+	}
+	c.updateDout()
+}
+
+func (c *Chip) convert(now time.Time) {
+	if c.pulses > 0 && c.pulses < dataBits {
+		// the driver is still shifting out the previous
+		// conversion; the new result is lost
+		c.violation(now, ErrReadOverrun)
+		return
+	}
+	c.endRead(now)
+	c.gain = c.nextGain
+	c.value = uint32(c.source(c.conversions, c.gain)) & (1<<dataBits - 1)
+	c.conversions++
+	c.ready = true
+}
+
+// endRead closes out any read in progress
+func (c *Chip) endRead(now time.Time) {
+	if c.pulses == 0 {
+		return
+	}
+	if c.pulses < pulsesA128 {
+		c.violation(now, ErrTooFewPulses)
+	}
+	c.stats.Reads++
+	c.stats.LastReadPulses = c.pulses
+	c.pulses = 0
+}
+
+func (c *Chip) updateDout() {
+	level := gpio.High
+	switch {
+	case !c.powerOn:
+	case c.pulses > 0 && c.pulses <= dataBits:
+		level = c.value&(1<<uint(dataBits-c.pulses)) != 0
+	case c.pulses == 0 && c.ready:
+		level = gpio.Low
+	}
+	if c.dout == gpio.High && level == gpio.Low &&
+		(c.edge == gpio.FallingEdge || c.edge == gpio.BothEdges) {
+		c.pendingEdge = true
+	}
+	if c.dout == gpio.Low && level == gpio.High &&
+		(c.edge == gpio.RisingEdge || c.edge == gpio.BothEdges) {
+		c.pendingEdge = true
+	}
+	c.dout = level
+}
+
+func (c *Chip) rise(now time.Time) {
+	c.advance(now)
+	if c.clkHigh {
+		return
+	}
+	c.clkHigh = true
+	c.clkRose = now
+	if !c.powerOn {
+		return
+	}
+	c.stats.Pulses++
+	// a pulse which can't be part of a read is either a protocol
+	// violation or the start of a power down, which can only be
+	// told apart on the falling edge
+	if c.pulses == 0 && !c.ready {
+		c.stray = ErrClockedWhileNotReady
+		return
+	}
+	if c.pulses == pulsesA64 {
+		c.stray = ErrTooManyPulses
+		return
+	}
+	c.pulses++
+	switch {
+	case c.pulses == dataBits:
+		// the conversion has been read out
+		c.ready = false
+	case c.pulses > dataBits:
+		c.nextGain = hx711.Gain(c.pulses - dataBits)
+	}
+	c.updateDout()
+}
+
+func (c *Chip) fall(now time.Time) {
+	c.advance(now)
+	if !c.clkHigh {
+		return
+	}
+	c.clkHigh = false
+	high := now.Sub(c.clkRose)
+	stray := c.stray
+	c.stray = nil
+	switch {
+	case high >= powerDownTime:
+		// returning from power down resets the chip
+		c.powerOn = true
+		c.gain = hx711.ChannelA128
+		c.nextGain = hx711.ChannelA128
+		c.ready = false
+		c.pulses = 0
+		c.next = now.Add(c.interval)
+		c.stats.PowerCycles++
+	case high > maxPulseHigh:
+		c.violation(now, ErrPulseTooLong)
+	case stray != nil:
+		c.violation(now, stray)
+	}
+	c.updateDout()
+}
+
+func (c *Chip) out(l gpio.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if l == gpio.High {
+		c.rise(now)
+	} else {
+		c.fall(now)
+	}
+}
+
+func (c *Chip) read() gpio.Level {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance(time.Now())
+	return c.dout
+}
+
+// waitForEdge emulates edge detection on DOUT. As on real hardware,
+// edges which happened since the last call are reported immediately.
+func (c *Chip) waitForEdge(timeout time.Duration) bool {
+	var deadline time.Time
+	if timeout >= 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		c.advance(now)
+		if c.edge == gpio.NoEdge {
+			c.mu.Unlock()
+			return false
+		}
+		if c.pendingEdge {
+			c.pendingEdge = false
+			c.mu.Unlock()
+			return true
+		}
+		wake := c.next
+		if !c.powerOn {
+			// nothing changes until the clock is driven
+			wake = now.Add(time.Millisecond)
+		}
+		c.mu.Unlock()
+
+		if !deadline.IsZero() {
+			if !now.Before(deadline) {
+				return false
+			}
+			if deadline.Before(wake) {
+				wake = deadline
+			}
+		}
+		time.Sleep(time.Until(wake))
+	}
+}
+
+type clockPin struct {
+	c *Chip
+}
+
+func (p *clockPin) String() string   { return p.Name() }
+func (p *clockPin) Halt() error      { return nil }
+func (p *clockPin) Name() string     { return "PD_SCK" }
+func (p *clockPin) Number() int      { return 0 }
+func (p *clockPin) Function() string { return "Out" }
+
+func (p *clockPin) In(gpio.Pull, gpio.Edge) error  { return errNotInput }
+func (p *clockPin) WaitForEdge(time.Duration) bool { return false }
+func (p *clockPin) Pull() gpio.Pull                { return gpio.PullNoChange }
+func (p *clockPin) DefaultPull() gpio.Pull         { return gpio.Float }
+
+func (p *clockPin) Read() gpio.Level {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	return gpio.Level(p.c.clkHigh)
+}
+
+func (p *clockPin) Out(l gpio.Level) error {
+	p.c.out(l)
+	return nil
+}
+
+func (p *clockPin) PWM(gpio.Duty, physic.Frequency) error { return errNoPWM }
+
+type dataPin struct {
+	c *Chip
+}
+
+func (p *dataPin) String() string   { return p.Name() }
+func (p *dataPin) Halt() error      { return nil }
+func (p *dataPin) Name() string     { return "DOUT" }
+func (p *dataPin) Number() int      { return 1 }
+func (p *dataPin) Function() string { return "In" }
+
+func (p *dataPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	p.c.pull = pull
+	p.c.edge = edge
+	p.c.pendingEdge = false
+	return nil
+}
+
+func (p *dataPin) Read() gpio.Level { return p.c.read() }
+
+func (p *dataPin) WaitForEdge(timeout time.Duration) bool {
+	return p.c.waitForEdge(timeout)
+}
+
+func (p *dataPin) Pull() gpio.Pull {
+	p.c.mu.Lock()
+	defer p.c.mu.Unlock()
+	return p.c.pull
+}
+
+func (p *dataPin) DefaultPull() gpio.Pull { return gpio.Float }
+
+func (p *dataPin) Out(gpio.Level) error                  { return errNotOutput }
+func (p *dataPin) PWM(gpio.Duty, physic.Frequency) error { return errNoPWM }
@@ -20,6 +20,14 @@ const (
 	t3            = time.Microsecond      // T_3 typical pd_sck high time
 	t4            = time.Microsecond      // T_4 typical pd_sck low time
 	powerDownTime = 60 * time.Microsecond // time to hold pd_sck at HIGH to signal power down
+
+	// edgeWaitSlice bounds each wait for an edge so that context
+	// cancellation is noticed promptly
+	edgeWaitSlice = 10 * time.Millisecond
+
+	// rateDetectConversions is the number of conversion intervals
+	// timed when detecting the sample rate
+	rateDetectConversions = 3
 )
 
 var (
@@ -38,59 +46,79 @@ type dev struct {
 	// Mutable.
 	mu        sync.Mutex
 	inputMode Gain
+	waitMode  WaitMode
 	done      chan<- struct{}
 	powerOn   bool
+
+	// interval is the measured conversion interval, or zero if it
+	// has not been measured
+	interval time.Duration
+	// seq and lastSample track conversions for numbering samples
+	seq        uint64
+	lastSample time.Time
 }
 
 // New creates a new HX711 device.
 //
-// The data pin must support edge detection. If your pin doesn't natively
-// support edge detection you can use PollEdge from gpioutil.
-func New(clk gpio.PinOut, data gpio.PinIn) (V2, error) {
-	if err := data.In(gpio.PullDown, gpio.NoEdge); err != nil {
-		return nil, err
-	}
-	if err := clk.Out(gpio.Low); err != nil {
-		return nil, err
-	}
-	return &dev{
+// By default the driver busy polls the data pin while waiting for a
+// conversion. See WithEdgeDetection.
+func New(clk gpio.PinOut, data gpio.PinIn, opts ...Option) (V2, error) {
+	d := &dev{
 		name:      "hx711{" + clk.Name() + ", " + data.Name() + "}",
 		inputMode: ChannelA128,
+		waitMode:  WaitPolling,
 		clk:       clk,
 		data:      data,
 		done:      nil,
 		powerOn:   true,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	if err := d.setupPins(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// WaitMode implements WaitModeReporter
+func (d *dev) WaitMode() WaitMode {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.waitMode
 }
 
 // ReadContinuous implements measurement.StreamingSensor
-func (d *dev) ReadContinuous() <-chan measurement.TimeSeriesSample {
+func (d *dev) ReadContinuous(ctx context.Context, opts ...measurement.StreamOption) <-chan measurement.TimeSeriesSample {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	if d.done != nil {
 		// read already in progress
 		return nil
 	}
-	done := make(chan struct{})
-	d.done = done
-
 	if !d.powerOn {
 		return nil
 	}
+	done := make(chan struct{})
+	d.done = done
 
-	ctx, cancel := context.WithCancel(context.TODO())
+	ctx, cancel := context.WithCancel(ctx)
 	go func() {
-		<-done
-		cancel()
+		defer cancel()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
 	}()
 
-	out := make(chan measurement.TimeSeriesSample)
-	go d.stream(ctx, out)
-	return out
+	s := measurement.NewSampleStream(ctx, opts...)
+	go d.stream(ctx, s, done)
+	return s.C()
 }
 
-func (d *dev) stream(ctx context.Context, out chan<- measurement.TimeSeriesSample) {
-	defer close(out)
+func (d *dev) stream(ctx context.Context, s *measurement.SampleStream, done chan<- struct{}) {
+	defer s.Close()
+	defer d.endStream(done)
 	for {
 		select {
 		case <-ctx.Done():
@@ -99,11 +127,22 @@ func (d *dev) stream(ctx context.Context, out chan<- measurement.TimeSeriesSampl
 		}
 		ts, err := d.Read(ctx)
 		if err == nil {
-			out <- ts
+			s.Push(ts)
 		}
 	}
 }
 
+// endStream allows a new stream to be started once the stream
+// identified by done has stopped
+func (d *dev) endStream(done chan<- struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done == done {
+		close(d.done)
+		d.done = nil
+	}
+}
+
 // Halt implements conn.Resource
 func (d *dev) Halt() error {
 	d.mu.Lock()
@@ -116,6 +155,9 @@ func (d *dev) stop() error {
 		close(d.done)
 		d.done = nil
 	}
+	// conversions stop while powered down, so don't count the
+	// time as missed samples
+	d.lastSample = time.Time{}
 	if err := d.clk.Out(gpio.High); err != nil {
 		return err
 	}
@@ -155,24 +197,53 @@ func (d *dev) waitForReady(ctx context.Context) error {
 		return ErrStopped
 	}
 
-	// TODO(rchew): for some reason making this wait more
-	// coarsely grained by adding time.Sleep results in
-	// the device intermittently resetting
+	if d.waitMode == WaitEdge {
+		if err := d.waitForEdge(ctx); err != nil {
+			return err
+		}
+	}
+	if d.waitMode == WaitPolling {
+		// TODO(rchew): for some reason making this wait more
+		// coarsely grained by adding time.Sleep results in
+		// the device intermittently resetting
+		for !d.ready() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+	}
+
+	// after DOUT falling edge, wait T_1 for data to be ready
+	nanospin(t1)
+	return nil
+}
+
+// waitForEdge blocks until DOUT falls. Edges are also produced while
+// clocking out data, so the pin level is always checked on waking.
+func (d *dev) waitForEdge(ctx context.Context) error {
 	for !d.ready() {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
+		start := time.Now()
+		if !d.data.WaitForEdge(edgeWaitSlice) && time.Since(start) < edgeWaitSlice/2 {
+			// the pin returned without waiting, so it cannot
+			// detect edges after all
+			d.waitMode = WaitPolling
+			return d.setupPins()
+		}
 	}
-
-	// after DOUT falling edge, wait T_1 for data to be ready
-	nanospin(t1)
 	return nil
 }
 
 func (d *dev) readSample() (measurement.TimeSeriesSample, error) {
 	timestamp := time.Now()
+	d.seq += d.conversionsSince(timestamp)
+	d.lastSample = timestamp
 	v, err := d.readRaw()
 	if err != nil {
 		return measurement.TimeSeriesSample{}, err
@@ -180,9 +251,64 @@ func (d *dev) readSample() (measurement.TimeSeriesSample, error) {
 	return measurement.TimeSeriesSample{
 		Sample: analog.Sample{Raw: v},
 		Time:   timestamp,
+		Seq:    d.seq,
 	}, nil
 }
 
+// conversionsSince estimates how many conversions the device has
+// made since the previous sample was read. Until the sample rate has
+// been detected every read is assumed to be the next conversion.
+func (d *dev) conversionsSince(t time.Time) uint64 {
+	if d.interval == 0 || d.lastSample.IsZero() {
+		return 1
+	}
+	n := (t.Sub(d.lastSample) + d.interval/2) / d.interval
+	if n < 1 {
+		return 1
+	}
+	return uint64(n)
+}
+
+// DetectSampleRate implements SampleRateDetector
+func (d *dev) DetectSampleRate(ctx context.Context) (SampleRate, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.detectSampleRate(ctx)
+}
+
+func (d *dev) detectSampleRate(ctx context.Context) (SampleRate, error) {
+	// throw away any conversion which is already waiting, so that
+	// only fresh conversions are timed
+	if _, err := d.blockingRead(ctx); err != nil && err != ErrBadRead {
+		return 0, err
+	}
+	var first, last time.Time
+	for i := 0; i <= rateDetectConversions; i++ {
+		if err := d.waitForReady(ctx); err != nil {
+			return 0, err
+		}
+		last = time.Now()
+		if i == 0 {
+			first = last
+		}
+		if _, err := d.readSample(); err != nil && err != ErrBadRead {
+			return 0, err
+		}
+	}
+	d.interval = last.Sub(first) / rateDetectConversions
+	return NearestSampleRate(d.interval), nil
+}
+
+// SampleRate implements SampleRateDetector
+func (d *dev) SampleRate() (SampleRate, time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.interval == 0 {
+		return 0, 0, false
+	}
+	return NearestSampleRate(d.interval), d.interval, true
+}
+
 func (d *dev) readRaw() (int32, error) {
 	// Shift the 24-bit 2's compliment value.
 	var value uint32
@@ -222,6 +348,9 @@ func (d *dev) readRaw() (int32, error) {
 	if r&(r+1) == 0 { // if r is a sequence of 0s followed by a sequence of 1s
 		return r, ErrBadRead
 	}
+	if r == -(1 << 23) { // the output saturates at 800000h below the input range
+		return r, ErrBadRead
+	}
 	return r, nil
 }
 
@@ -265,6 +394,13 @@ func (d *dev) SetGain(ctx context.Context, g Gain) error {
 	return err
 }
 
+// Gain implements GainReporter
+func (d *dev) Gain() Gain {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inputMode
+}
+
 // Range implements measurement.Sensor
 func (d *dev) Range() (analog.Sample, analog.Sample) {
 	return analog.Sample{Raw: -(1 << 23)}, analog.Sample{Raw: 1 << 23}
@@ -290,10 +426,24 @@ func (d *dev) Reset(ctx context.Context) error {
 		}
 	}
 
+	// the sample rate is fixed in hardware, so it only needs to be
+	// measured once
+	if d.interval == 0 {
+		if _, err := d.detectSampleRate(ctx); err != nil {
+			return err
+		}
+	}
+
 	return d.waitForReady(ctx)
 }
 
 func (d *dev) setupPins() error {
+	if d.waitMode == WaitEdge {
+		if err := d.data.In(gpio.PullDown, gpio.FallingEdge); err == nil {
+			return d.clk.Out(gpio.Low)
+		}
+		d.waitMode = WaitPolling
+	}
 	if err := d.data.In(gpio.PullDown, gpio.NoEdge); err != nil {
 		return err
 	}
@@ -0,0 +1,237 @@
+package hx711_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/hx711/hx711test"
+)
+
+const (
+	testTimeout = 5 * time.Second
+
+	// attempts bounds how often a scenario is run. The host can
+	// deschedule the driver while PD_SCK is high, which the chip
+	// rightly treats as a timing violation or a power down, so a
+	// failed attempt is retried on a fresh chip. Driver bugs fail
+	// every attempt.
+	attempts = 5
+)
+
+// scenario drives d, returning an error if it misbehaves
+type scenario func(ctx context.Context, d hx711.V2, chip *hx711test.Chip) error
+
+func run(t *testing.T, source hx711test.Source, opts []hx711.Option, s scenario) {
+	t.Helper()
+	runChip(t, source, func(ctx context.Context, chip *hx711test.Chip) error {
+		d, err := hx711.New(chip.Clock(), chip.Data(), opts...)
+		if err != nil {
+			return fmt.Errorf("New: %v", err)
+		}
+		return s(ctx, d, chip)
+	})
+}
+
+// runChip runs a scenario which sets up its own driver for the chip
+func runChip(t *testing.T, source hx711test.Source, s func(ctx context.Context, chip *hx711test.Chip) error) {
+	t.Helper()
+	var err error
+	var violations []hx711test.Violation
+	for i := 0; i < attempts; i++ {
+		violations, err = attempt(source, s)
+		if err == nil && len(violations) == 0 {
+			return
+		}
+	}
+	if err != nil {
+		t.Error(err)
+	}
+	for _, v := range violations {
+		t.Errorf("protocol violation: %v", v.Err)
+	}
+}
+
+func attempt(source hx711test.Source, s func(ctx context.Context, chip *hx711test.Chip) error) ([]hx711test.Violation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	chip := hx711test.NewChip(source, hx711.Rate80SPS)
+	err := s(ctx, chip)
+	return chip.Stats().Violations, err
+}
+
+func TestPulsesPerGain(t *testing.T) {
+	for _, tc := range []struct {
+		gain   hx711.Gain
+		pulses int
+	}{
+		{hx711.ChannelA128, 25},
+		{hx711.ChannelA64, 27},
+		{hx711.ChannelB32, 26},
+	} {
+		t.Run(tc.gain.String(), func(t *testing.T) {
+			run(t, hx711test.Values(1000), nil, func(ctx context.Context, d hx711.V2, chip *hx711test.Chip) error {
+				if err := d.SetGain(ctx, tc.gain); err != nil {
+					return fmt.Errorf("SetGain: %v", err)
+				}
+				if _, err := d.Read(ctx); err != nil {
+					return fmt.Errorf("Read: %v", err)
+				}
+				// a read is only closed out by the next conversion
+				if _, err := d.Read(ctx); err != nil {
+					return fmt.Errorf("Read: %v", err)
+				}
+				s := chip.Stats()
+				if s.LastReadPulses != tc.pulses {
+					return fmt.Errorf("got %d pulses per read, want %d", s.LastReadPulses, tc.pulses)
+				}
+				if s.Gain != tc.gain {
+					return fmt.Errorf("chip converting at %v, want %v", s.Gain, tc.gain)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestReadValues(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  int32
+		err  error
+	}{
+		{"positive", 1234567, nil},
+		{"negative", -1234567, nil},
+		{"minus two", -2, nil},
+		{"smallest valid", -(1 << 23) + 1, nil},
+		{"largest valid", 1<<23 - 2, nil},
+		{"saturated high", 0x7FFFFF, hx711.ErrBadRead},
+		{"saturated low", -(1 << 23), hx711.ErrBadRead},
+		{"all ones", -1, hx711.ErrBadRead},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, hx711test.Values(tc.raw), nil, func(ctx context.Context, d hx711.V2, _ *hx711test.Chip) error {
+				ts, err := d.Read(ctx)
+				if err != tc.err {
+					return fmt.Errorf("got error %v, want %v", err, tc.err)
+				}
+				if err == nil && ts.Raw != tc.raw {
+					return fmt.Errorf("got raw value %d, want %d", ts.Raw, tc.raw)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestTryReadNotReady(t *testing.T) {
+	run(t, hx711test.Values(1000), nil, func(_ context.Context, d hx711.V2, chip *hx711test.Chip) error {
+		if _, err := d.TryRead(); err != hx711.ErrNotReady {
+			return fmt.Errorf("got error %v before first conversion, want %v", err, hx711.ErrNotReady)
+		}
+		if s := chip.Stats(); s.Pulses != 0 {
+			return fmt.Errorf("clocked %d pulses while not ready", s.Pulses)
+		}
+		return nil
+	})
+}
+
+func TestHaltReset(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []hx711.Option
+	}{
+		{"polling", nil},
+		{"edge", []hx711.Option{hx711.WithEdgeDetection()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			run(t, hx711test.Values(1000), tc.opts, haltReset)
+		})
+	}
+}
+
+func haltReset(ctx context.Context, d hx711.V2, chip *hx711test.Chip) error {
+	if err := d.SetGain(ctx, hx711.ChannelA64); err != nil {
+		return fmt.Errorf("SetGain: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if err := d.Halt(); err != nil {
+			return fmt.Errorf("Halt: %v", err)
+		}
+		if s := chip.Stats(); s.PoweredOn {
+			return fmt.Errorf("chip still powered on after Halt")
+		}
+		if _, err := d.Read(ctx); err != hx711.ErrStopped {
+			return fmt.Errorf("got error %v reading while halted, want %v", err, hx711.ErrStopped)
+		}
+		if d.ReadContinuous(ctx) != nil {
+			return fmt.Errorf("started a stream while halted")
+		}
+
+		if err := d.Reset(ctx); err != nil {
+			return fmt.Errorf("Reset: %v", err)
+		}
+		s := chip.Stats()
+		if !s.PoweredOn {
+			return fmt.Errorf("chip not powered on after Reset")
+		}
+		if s.PowerCycles != i {
+			return fmt.Errorf("got %d power cycles, want %d", s.PowerCycles, i)
+		}
+		// powering up selects A128, so the driver must restore
+		// the gain
+		if s.Gain != hx711.ChannelA64 {
+			return fmt.Errorf("chip converting at %v after Reset, want %v", s.Gain, hx711.ChannelA64)
+		}
+		if ts, err := d.Read(ctx); err != nil || ts.Raw != 1000 {
+			return fmt.Errorf("Read after Reset = %d, %v", ts.Raw, err)
+		}
+	}
+
+	r, ok := d.(hx711.SampleRateDetector)
+	if !ok {
+		return fmt.Errorf("driver does not detect its sample rate")
+	}
+	if rate, _, ok := r.SampleRate(); !ok || rate != hx711.Rate80SPS {
+		return fmt.Errorf("got sample rate %v (detected %v), want %v", rate, ok, hx711.Rate80SPS)
+	}
+	return nil
+}
+
+func TestReadContinuous(t *testing.T) {
+	run(t, hx711test.Values(10, 20, 30), nil, func(ctx context.Context, d hx711.V2, _ *hx711test.Chip) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		c := d.ReadContinuous(ctx)
+		if c == nil {
+			return fmt.Errorf("ReadContinuous returned no stream")
+		}
+		defer func() {
+			cancel()
+			for range c {
+			}
+		}()
+		if d.ReadContinuous(ctx) != nil {
+			return fmt.Errorf("started a second concurrent stream")
+		}
+
+		var last uint64
+		for i := 0; i < 6; i++ {
+			ts, ok := <-c
+			if !ok {
+				return fmt.Errorf("stream closed early")
+			}
+			if want := int32(10 * (i%3 + 1)); ts.Raw != want {
+				return fmt.Errorf("sample %d: got raw value %d, want %d", i, ts.Raw, want)
+			}
+			if ts.Seq <= last {
+				return fmt.Errorf("sample %d: sequence number %d did not increase from %d", i, ts.Seq, last)
+			}
+			last = ts.Seq
+		}
+		return nil
+	})
+}
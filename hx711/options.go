@@ -0,0 +1,21 @@
+package hx711
+
+type Option interface {
+	apply(d *dev)
+}
+
+type optFn func(d *dev)
+
+func (fn optFn) apply(d *dev) {
+	fn(d)
+}
+
+// WithEdgeDetection makes the driver block on a falling edge of the
+// data pin while waiting for a conversion, instead of busy polling.
+// If the data pin does not support edge detection the driver falls
+// back to polling.
+func WithEdgeDetection() Option {
+	return optFn(func(d *dev) {
+		d.waitMode = WaitEdge
+	})
+}
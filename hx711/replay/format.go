@@ -0,0 +1,168 @@
+// Package replay records the raw output of an hx711.V2 to a compact
+// binary log, and plays such logs back as an hx711.V2
+//
+// A log begins with a fixed header, followed by a sequence of
+// entries. Each entry is a one byte kind, the nanoseconds elapsed
+// since the previous entry as a uvarint, and a kind-specific
+// payload:
+//
+//	kindSample: raw reading as a zig-zag varint
+//	kindError:  one byte error code
+//	kindGain:   one byte hx711.Gain
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+)
+
+const (
+	magic   = "HX711RAW"
+	version = 1
+)
+
+type kind byte
+
+const (
+	kindSample kind = 1
+	kindError  kind = 2
+	kindGain   kind = 3
+)
+
+type errCode byte
+
+const (
+	errCodeBadRead errCode = 1
+)
+
+var (
+	ErrBadHeader       = errors.New("not a raw hx711 recording")
+	ErrEndOfRecording  = errors.New("end of recorded session")
+	errUnsupportedKind = errors.New("unsupported entry in recording")
+)
+
+type entry struct {
+	kind kind
+	time time.Time
+	raw  int32
+	err  error
+	gain hx711.Gain
+}
+
+func writeHeader(w io.Writer, start time.Time) error {
+	buf := make([]byte, 0, len(magic)+1+binary.MaxVarintLen64)
+	buf = append(buf, magic...)
+	buf = append(buf, version)
+	buf = appendVarint(buf, start.UnixNano())
+	_, err := w.Write(buf)
+	return err
+}
+
+func readHeader(r *bufio.Reader) (time.Time, error) {
+	hdr := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return time.Time{}, ErrBadHeader
+	}
+	if string(hdr[:len(magic)]) != magic {
+		return time.Time{}, ErrBadHeader
+	}
+	if v := hdr[len(magic)]; v != version {
+		return time.Time{}, fmt.Errorf("unsupported recording version %d", v)
+	}
+	start, err := binary.ReadVarint(r)
+	if err != nil {
+		return time.Time{}, ErrBadHeader
+	}
+	return time.Unix(0, start), nil
+}
+
+// encodeEntry appends e to buf, with its time relative to prev
+func encodeEntry(buf []byte, e entry, prev time.Time) []byte {
+	delta := e.time.Sub(prev)
+	if delta < 0 {
+		delta = 0
+	}
+	buf = append(buf, byte(e.kind))
+	buf = appendUvarint(buf, uint64(delta))
+	switch e.kind {
+	case kindSample:
+		buf = appendVarint(buf, int64(e.raw))
+	case kindError:
+		buf = append(buf, byte(errCodeBadRead))
+	case kindGain:
+		buf = append(buf, byte(e.gain))
+	}
+	return buf
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func decodeEntry(r *bufio.Reader, prev time.Time) (entry, error) {
+	k, err := r.ReadByte()
+	if err == io.EOF {
+		return entry{}, ErrEndOfRecording
+	} else if err != nil {
+		return entry{}, err
+	}
+	delta, err := binary.ReadUvarint(r)
+	if err != nil {
+		return entry{}, truncated(err)
+	}
+	e := entry{
+		kind: kind(k),
+		time: prev.Add(time.Duration(delta)),
+	}
+	switch e.kind {
+	case kindSample:
+		raw, err := binary.ReadVarint(r)
+		if err != nil {
+			return entry{}, truncated(err)
+		}
+		e.raw = int32(raw)
+	case kindError:
+		code, err := r.ReadByte()
+		if err != nil {
+			return entry{}, truncated(err)
+		}
+		switch errCode(code) {
+		case errCodeBadRead:
+			e.err = hx711.ErrBadRead
+		default:
+			return entry{}, errUnsupportedKind
+		}
+	case kindGain:
+		g, err := r.ReadByte()
+		if err != nil {
+			return entry{}, truncated(err)
+		}
+		e.gain = hx711.Gain(g)
+	default:
+		return entry{}, errUnsupportedKind
+	}
+	return e, nil
+}
+
+// truncated treats a recording which ends partway through an entry,
+// e.g. because the recording process crashed, as a clean end
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrEndOfRecording
+	}
+	return err
+}
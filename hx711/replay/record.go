@@ -0,0 +1,121 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/measurement"
+)
+
+// Record wraps hx so that every sample and read error it produces,
+// and every change of gain, is written to w. Each entry is written
+// with a single call to w, so a recording is usable up to the last
+// complete entry even if the process is killed.
+func Record(hx hx711.V2, w io.Writer) (hx711.V2, error) {
+	start := time.Now()
+	if err := writeHeader(w, start); err != nil {
+		return nil, err
+	}
+	return &recorder{
+		V2:   hx,
+		w:    w,
+		prev: start,
+	}, nil
+}
+
+type recorder struct {
+	hx711.V2
+
+	mu   sync.Mutex
+	w    io.Writer
+	prev time.Time
+	buf  []byte
+	// err holds the first write error, after which recording stops
+	err       error
+	streaming bool
+}
+
+func (r *recorder) write(e entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.buf = encodeEntry(r.buf[:0], e, r.prev)
+	if e.time.After(r.prev) {
+		r.prev = e.time
+	}
+	_, r.err = r.w.Write(r.buf)
+}
+
+func (r *recorder) observe(ts measurement.TimeSeriesSample, err error) {
+	switch err {
+	case nil:
+		r.write(entry{kind: kindSample, time: ts.Time, raw: ts.Raw})
+	case hx711.ErrBadRead:
+		r.write(entry{kind: kindError, time: time.Now(), err: err})
+	}
+}
+
+// Read implements measurement.Sensor
+func (r *recorder) Read(ctx context.Context) (measurement.TimeSeriesSample, error) {
+	ts, err := r.V2.Read(ctx)
+	r.observe(ts, err)
+	return ts, err
+}
+
+// TryRead implements measurement.Sensor
+func (r *recorder) TryRead() (measurement.TimeSeriesSample, error) {
+	ts, err := r.V2.TryRead()
+	r.observe(ts, err)
+	return ts, err
+}
+
+// ReadContinuous implements measurement.StreamingSensor
+//
+// Samples are read with Read, so that bad reads are recorded as they
+// are outside a stream. Samples the stream drops for a slow consumer
+// are still recorded.
+func (r *recorder) ReadContinuous(ctx context.Context, opts ...measurement.StreamOption) <-chan measurement.TimeSeriesSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.streaming {
+		// read already in progress
+		return nil
+	}
+	r.streaming = true
+	s := measurement.NewSampleStream(ctx, opts...)
+	go r.stream(ctx, s)
+	return s.C()
+}
+
+func (r *recorder) stream(ctx context.Context, s *measurement.SampleStream) {
+	defer s.Close()
+	defer func() {
+		r.mu.Lock()
+		r.streaming = false
+		r.mu.Unlock()
+	}()
+	for ctx.Err() == nil {
+		ts, err := r.Read(ctx)
+		switch err {
+		case nil:
+			s.Push(ts)
+		case hx711.ErrBadRead:
+		default:
+			return
+		}
+	}
+}
+
+// SetGain implements hx711.V2
+func (r *recorder) SetGain(ctx context.Context, g hx711.Gain) error {
+	if err := r.V2.SetGain(ctx, g); err != nil {
+		return err
+	}
+	r.write(entry{kind: kindGain, time: time.Now(), gain: g})
+	return nil
+}
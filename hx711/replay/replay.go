@@ -0,0 +1,326 @@
+package replay
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/measurement"
+	"periph.io/x/periph/experimental/conn/analog"
+)
+
+type Option interface {
+	apply(p *player)
+}
+
+type optFn func(p *player)
+
+func (fn optFn) apply(p *player) {
+	fn(p)
+}
+
+// AsFastAsPossible plays a recording back without waiting between
+// samples. Sample timestamps keep their original spacing.
+func AsFastAsPossible() Option {
+	return optFn(func(p *player) {
+		p.realtime = false
+	})
+}
+
+// WithName overrides the name reported by String
+func WithName(name string) Option {
+	return optFn(func(p *player) {
+		p.name = name
+	})
+}
+
+// Open returns an hx711.V2 which plays back a recording made by
+// Record. Samples are timestamped relative to when Open was called,
+// with the same spacing as when they were recorded. Once the
+// recording is exhausted reads return ErrEndOfRecording.
+func Open(r io.Reader, opts ...Option) (hx711.V2, error) {
+	br := bufio.NewReader(r)
+	origin, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	p := &player{
+		name:     "hx711{replay}",
+		realtime: true,
+		r:        br,
+		origin:   origin,
+		prev:     origin,
+		powerOn:  true,
+		halt:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+	p.start = time.Now()
+	return p, nil
+}
+
+type player struct {
+	// Immutable.
+	name     string
+	realtime bool
+	origin   time.Time
+	start    time.Time
+
+	// Mutable.
+	mu      sync.Mutex
+	r       *bufio.Reader
+	prev    time.Time
+	next    *entry
+	nextErr error
+	seq     uint64
+	done    chan<- struct{}
+	powerOn bool
+	// halt is closed when the player is halted, waking reads waiting
+	// for the next sample to be due
+	halt chan struct{}
+}
+
+// dueAt maps a recorded time onto the playback clock
+func (p *player) dueAt(t time.Time) time.Time {
+	return p.start.Add(t.Sub(p.origin))
+}
+
+// peek returns the next sample or error entry in the recording,
+// skipping over gain changes
+func (p *player) peek() (*entry, error) {
+	for p.next == nil && p.nextErr == nil {
+		e, err := decodeEntry(p.r, p.prev)
+		if err != nil {
+			p.nextErr = err
+			break
+		}
+		p.prev = e.time
+		if e.kind == kindGain {
+			continue
+		}
+		p.next = &e
+	}
+	return p.next, p.nextErr
+}
+
+func (p *player) consume() (measurement.TimeSeriesSample, error) {
+	e := p.next
+	p.next = nil
+	p.seq++
+	if e.err != nil {
+		return measurement.TimeSeriesSample{}, e.err
+	}
+	return measurement.TimeSeriesSample{
+		Sample: analog.Sample{Raw: e.raw},
+		Time:   p.dueAt(e.time),
+		Seq:    p.seq,
+	}, nil
+}
+
+func (p *player) ready(now time.Time) bool {
+	if !p.powerOn {
+		return false
+	}
+	e, err := p.peek()
+	if err != nil {
+		// let the caller read out the error
+		return true
+	}
+	return !p.realtime || !p.dueAt(e.time).After(now)
+}
+
+// ReadContinuous implements measurement.StreamingSensor
+//
+// The channel is also closed when the recording is exhausted.
+func (p *player) ReadContinuous(ctx context.Context, opts ...measurement.StreamOption) <-chan measurement.TimeSeriesSample {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done != nil {
+		// read already in progress
+		return nil
+	}
+	if !p.powerOn {
+		return nil
+	}
+	done := make(chan struct{})
+	p.done = done
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}()
+
+	s := measurement.NewSampleStream(ctx, opts...)
+	go p.stream(ctx, s, done)
+	return s.C()
+}
+
+func (p *player) stream(ctx context.Context, s *measurement.SampleStream, done chan<- struct{}) {
+	defer s.Close()
+	defer p.endStream(done)
+	for {
+		ts, err := p.Read(ctx)
+		switch err {
+		case nil:
+			s.Push(ts)
+		case hx711.ErrBadRead:
+		default:
+			return
+		}
+	}
+}
+
+// endStream allows a new stream to be started once the stream
+// identified by done has stopped
+func (p *player) endStream(done chan<- struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done == done {
+		close(p.done)
+		p.done = nil
+	}
+}
+
+// Halt implements conn.Resource
+func (p *player) Halt() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+	if p.powerOn {
+		close(p.halt)
+	}
+	p.powerOn = false
+	return nil
+}
+
+// IsReady implements measurement.Sensor
+func (p *player) IsReady() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ready(time.Now())
+}
+
+// Read implements measurement.Sensor
+//
+// The lock is not held while waiting for the next sample to be due,
+// so the player can be halted or polled meanwhile.
+func (p *player) Read(ctx context.Context) (measurement.TimeSeriesSample, error) {
+	for {
+		p.mu.Lock()
+		due, err := p.due()
+		if err == nil && !due.After(time.Now()) {
+			ts, err := p.consume()
+			p.mu.Unlock()
+			return ts, err
+		}
+		halt := p.halt
+		p.mu.Unlock()
+		if err != nil {
+			return measurement.TimeSeriesSample{}, err
+		}
+		if err := sleepUntil(ctx, due, halt); err != nil {
+			return measurement.TimeSeriesSample{}, err
+		}
+	}
+}
+
+// due returns when the next entry in the recording is due, which is
+// the zero time if it is due straight away. It must be called with
+// p.mu held.
+func (p *player) due() (time.Time, error) {
+	if !p.powerOn {
+		return time.Time{}, hx711.ErrStopped
+	}
+	e, err := p.peek()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !p.realtime {
+		return time.Time{}, nil
+	}
+	return p.dueAt(e.time), nil
+}
+
+// sleepUntil waits until t, returning early if ctx is done or the
+// player is halted
+func sleepUntil(ctx context.Context, t time.Time, halt <-chan struct{}) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-halt:
+		return hx711.ErrStopped
+	case <-timer.C:
+	}
+	return nil
+}
+
+// TryRead implements measurement.Sensor
+func (p *player) TryRead() (measurement.TimeSeriesSample, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.powerOn {
+		return measurement.TimeSeriesSample{}, hx711.ErrStopped
+	}
+	if !p.ready(time.Now()) {
+		return measurement.TimeSeriesSample{}, hx711.ErrNotReady
+	}
+	if _, err := p.peek(); err != nil {
+		return measurement.TimeSeriesSample{}, err
+	}
+	return p.consume()
+}
+
+// SetGain implements hx711.V2
+//
+// The recording already reflects the gain used when it was made, so
+// this only validates the requested gain.
+func (p *player) SetGain(_ context.Context, g hx711.Gain) error {
+	switch g {
+	default:
+		return hx711.ErrGainUnavailable
+	case hx711.ChannelA128:
+	case hx711.ChannelA64:
+	case hx711.ChannelB32:
+	}
+	return nil
+}
+
+// Range implements measurement.Sensor
+func (p *player) Range() (analog.Sample, analog.Sample) {
+	return analog.Sample{Raw: -(1 << 23)}, analog.Sample{Raw: 1 << 23}
+}
+
+// String implements conn.Resource
+func (p *player) String() string { return p.name }
+
+// Reset implements measurement.Sensor
+//
+// Resetting does not rewind the recording.
+func (p *player) Reset(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.powerOn {
+		p.halt = make(chan struct{})
+	}
+	p.powerOn = true
+	due, err := p.due()
+	halt := p.halt
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return sleepUntil(ctx, due, halt)
+}
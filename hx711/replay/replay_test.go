@@ -0,0 +1,198 @@
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/hx711/replay"
+	"github.com/chewr/tension-scale/hx711/sim"
+	"github.com/chewr/tension-scale/measurement"
+)
+
+type read struct {
+	raw     int32
+	elapsed time.Duration
+	err     error
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := sim.New(
+		sim.Constant(100000),
+		sim.WithSampleRate(hx711.Rate80SPS),
+		sim.WithNoise(500),
+		sim.WithGlitches(0.2),
+		sim.WithSeed(1),
+	)
+	buf := new(bytes.Buffer)
+	rec, err := replay.Record(src, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []read
+	var first time.Time
+	for len(want) < 40 {
+		if len(want) == 20 {
+			if err := rec.SetGain(ctx, hx711.ChannelA64); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ts, err := rec.Read(ctx)
+		if err != nil && err != hx711.ErrBadRead {
+			t.Fatal(err)
+		}
+		r := read{err: err}
+		if err == nil {
+			if first.IsZero() {
+				first = ts.Time
+			}
+			r.raw, r.elapsed = ts.Raw, ts.Time.Sub(first)
+		}
+		want = append(want, r)
+	}
+
+	player, err := replay.Open(bytes.NewReader(buf.Bytes()), replay.AsFastAsPossible())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first = time.Time{}
+	var prevSeq uint64
+	for i, w := range want {
+		ts, err := player.Read(ctx)
+		if err != w.err {
+			t.Fatalf("read %d: got error %v, want %v", i, err, w.err)
+		}
+		if err != nil {
+			continue
+		}
+		if first.IsZero() {
+			first = ts.Time
+		}
+		if ts.Raw != w.raw {
+			t.Errorf("read %d: got raw %d, want %d", i, ts.Raw, w.raw)
+		}
+		// timestamps are recorded to the nanosecond
+		if got := ts.Time.Sub(first); got != w.elapsed {
+			t.Errorf("read %d: got time %v, want %v", i, got, w.elapsed)
+		}
+		if ts.Seq <= prevSeq {
+			t.Errorf("read %d: seq %d not after %d", i, ts.Seq, prevSeq)
+		}
+		prevSeq = ts.Seq
+	}
+	if _, err := player.Read(ctx); err != replay.ErrEndOfRecording {
+		t.Errorf("got %v after the recording, want %v", err, replay.ErrEndOfRecording)
+	}
+}
+
+func TestHaltWhileReadWaits(t *testing.T) {
+	buf := new(bytes.Buffer)
+	rec, err := replay.Record(sim.New(sim.Constant(0), sim.WithSampleRate(hx711.Rate80SPS)), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second sample is due well after the first
+	if _, err := rec.Read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if _, err := rec.Read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	player, err := replay.Open(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := player.Read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := player.Read(context.Background())
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	returned := make(chan struct{})
+	go func() {
+		player.IsReady()
+		player.Halt()
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Halt blocked behind a waiting Read")
+	}
+	select {
+	case err := <-errs:
+		if err != hx711.ErrStopped {
+			t.Errorf("got %v from the waiting Read, want %v", err, hx711.ErrStopped)
+		}
+	// well before the second sample is due
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("Read kept waiting after Halt")
+	}
+}
+
+func TestRecordStream(t *testing.T) {
+	src := sim.New(
+		sim.Constant(100000),
+		sim.WithSampleRate(hx711.Rate80SPS),
+		sim.WithNoise(500),
+		sim.WithGlitches(0.3),
+		sim.WithSeed(1),
+	)
+	buf := new(bytes.Buffer)
+	rec, err := replay.Record(src, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := rec.ReadContinuous(ctx, measurement.WithBufferSize(64))
+	if rec.ReadContinuous(ctx) != nil {
+		t.Error("started a second stream while the first was running")
+	}
+	var want []int32
+	for len(want) < 30 {
+		select {
+		case ts := <-stream:
+			want = append(want, ts.Raw)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d samples from the stream, want 30", len(want))
+		}
+	}
+	cancel()
+	for range stream {
+	}
+
+	player, err := replay.Open(bytes.NewReader(buf.Bytes()), replay.AsFastAsPossible())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int32
+	var badReads int
+	for len(got) < len(want) {
+		ts, err := player.Read(context.Background())
+		switch err {
+		case nil:
+			got = append(got, ts.Raw)
+		case hx711.ErrBadRead:
+			badReads++
+		default:
+			t.Fatalf("after %d samples: %v", len(got), err)
+		}
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sample %d: got raw %d, want %d", i, got[i], want[i])
+		}
+	}
+	if badReads == 0 {
+		t.Error("no bad reads recorded from the stream")
+	}
+}
@@ -0,0 +1,309 @@
+// Package sim implements a simulated HX711 which produces readings
+// from a scripted load profile, so that the rest of the pipeline
+// can be run without hardware
+package sim
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/measurement"
+	"periph.io/x/periph/experimental/conn/analog"
+)
+
+const (
+	minRaw = -(1 << 23)
+	maxRaw = 1<<23 - 1
+)
+
+// dev is a simulated hx711.
+type dev struct {
+	// Immutable.
+	name       string
+	profile    Profile
+	rate       hx711.SampleRate
+	offset     int32
+	noise      float64
+	drift      float64
+	glitchRate float64
+	seed       int64
+
+	// Mutable.
+	mu        sync.Mutex
+	rng       *rand.Rand
+	inputMode hx711.Gain
+	done      chan<- struct{}
+	powerOn   bool
+	// start is the time at which the profile began playing
+	start time.Time
+	// epoch is the time of the first conversion after power on
+	epoch time.Time
+	// consumed is the index of the last conversion read out
+	consumed int64
+	// seq numbers conversions across power cycles
+	seq uint64
+}
+
+// New creates a simulated HX711 which plays back the given profile,
+// starting from the time it is created
+func New(profile Profile, opts ...Option) hx711.V2 {
+	d := &dev{
+		name:      "hx711{sim}",
+		profile:   profile,
+		rate:      hx711.Rate10SPS,
+		seed:      time.Now().UnixNano(),
+		inputMode: hx711.ChannelA128,
+		powerOn:   true,
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	d.rng = rand.New(rand.NewSource(d.seed))
+	d.start = time.Now()
+	d.powerUp(d.start)
+	return d
+}
+
+func (d *dev) powerUp(now time.Time) {
+	d.powerOn = true
+	d.epoch = now.Add(d.rate.Interval())
+	d.consumed = -1
+}
+
+// conversion returns the index of the most recent conversion, or
+// -1 if none has completed since power on
+func (d *dev) conversion(now time.Time) int64 {
+	if now.Before(d.epoch) {
+		return -1
+	}
+	return int64(now.Sub(d.epoch) / d.rate.Interval())
+}
+
+func (d *dev) conversionTime(n int64) time.Time {
+	return d.epoch.Add(time.Duration(n) * d.rate.Interval())
+}
+
+// ReadContinuous implements measurement.StreamingSensor
+func (d *dev) ReadContinuous(ctx context.Context, opts ...measurement.StreamOption) <-chan measurement.TimeSeriesSample {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done != nil {
+		// read already in progress
+		return nil
+	}
+	if !d.powerOn {
+		return nil
+	}
+	done := make(chan struct{})
+	d.done = done
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}()
+
+	s := measurement.NewSampleStream(ctx, opts...)
+	go d.stream(ctx, s, done)
+	return s.C()
+}
+
+func (d *dev) stream(ctx context.Context, s *measurement.SampleStream, done chan<- struct{}) {
+	defer s.Close()
+	defer d.endStream(done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		ts, err := d.Read(ctx)
+		if err == nil {
+			s.Push(ts)
+		}
+	}
+}
+
+// endStream allows a new stream to be started once the stream
+// identified by done has stopped
+func (d *dev) endStream(done chan<- struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done == done {
+		close(d.done)
+		d.done = nil
+	}
+}
+
+// Halt implements conn.Resource
+func (d *dev) Halt() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done != nil {
+		close(d.done)
+		d.done = nil
+	}
+	d.powerOn = false
+	return nil
+}
+
+// IsReady implements measurement.Sensor
+func (d *dev) IsReady() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ready(time.Now())
+}
+
+func (d *dev) ready(now time.Time) bool {
+	return d.powerOn && d.conversion(now) > d.consumed
+}
+
+// Read implements measurement.Sensor
+func (d *dev) Read(ctx context.Context) (measurement.TimeSeriesSample, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.blockingRead(ctx)
+}
+
+func (d *dev) blockingRead(ctx context.Context) (measurement.TimeSeriesSample, error) {
+	if err := d.waitForReady(ctx); err != nil {
+		return measurement.TimeSeriesSample{}, err
+	}
+	return d.readSample(time.Now())
+}
+
+func (d *dev) waitForReady(ctx context.Context) error {
+	if !d.powerOn {
+		return hx711.ErrStopped
+	}
+	now := time.Now()
+	if d.ready(now) {
+		return nil
+	}
+	t := time.NewTimer(d.conversionTime(d.consumed + 1).Sub(now))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+	}
+	return nil
+}
+
+func (d *dev) readSample(now time.Time) (measurement.TimeSeriesSample, error) {
+	n := d.conversion(now)
+	d.seq += uint64(n - d.consumed)
+	d.consumed = n
+	if d.glitchRate > 0 && d.rng.Float64() < d.glitchRate {
+		return measurement.TimeSeriesSample{}, hx711.ErrBadRead
+	}
+	return measurement.TimeSeriesSample{
+		Sample: analog.Sample{Raw: d.value(d.conversionTime(n))},
+		Time:   now,
+		Seq:    d.seq,
+	}, nil
+}
+
+// value computes the raw output of the conversion completed at t
+func (d *dev) value(t time.Time) int32 {
+	elapsed := t.Sub(d.start)
+	v := float64(d.profile.Raw(elapsed))
+	v += float64(d.offset)
+	v += d.drift * elapsed.Minutes()
+	if d.noise > 0 {
+		v += d.rng.NormFloat64() * d.noise
+	}
+	switch d.inputMode {
+	case hx711.ChannelA64:
+		v /= 2
+	case hx711.ChannelB32:
+		v /= 4
+	}
+	return int32(math.Max(minRaw, math.Min(maxRaw, math.Round(v))))
+}
+
+// TryRead implements measurement.Sensor
+func (d *dev) TryRead() (measurement.TimeSeriesSample, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.powerOn {
+		return measurement.TimeSeriesSample{}, hx711.ErrStopped
+	}
+
+	now := time.Now()
+	if !d.ready(now) {
+		return measurement.TimeSeriesSample{}, hx711.ErrNotReady
+	}
+	return d.readSample(now)
+}
+
+// SetGain implements hx711.V2
+func (d *dev) SetGain(ctx context.Context, g hx711.Gain) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch g {
+	default:
+		return hx711.ErrGainUnavailable
+	case hx711.ChannelA128:
+	case hx711.ChannelA64:
+	case hx711.ChannelB32:
+	}
+
+	d.inputMode = g
+
+	if !d.powerOn {
+		return nil
+	}
+
+	// read and throw away one data point to set gain, as the
+	// hardware would
+	_, err := d.blockingRead(ctx)
+	if err == hx711.ErrBadRead {
+		return nil
+	}
+	return err
+}
+
+// DetectSampleRate implements hx711.SampleRateDetector
+func (d *dev) DetectSampleRate(context.Context) (hx711.SampleRate, error) {
+	return d.rate, nil
+}
+
+// SampleRate implements hx711.SampleRateDetector
+func (d *dev) SampleRate() (hx711.SampleRate, time.Duration, bool) {
+	return d.rate, d.rate.Interval(), true
+}
+
+// Gain implements hx711.GainReporter
+func (d *dev) Gain() hx711.Gain {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inputMode
+}
+
+// Range implements measurement.Sensor
+func (d *dev) Range() (analog.Sample, analog.Sample) {
+	return analog.Sample{Raw: -(1 << 23)}, analog.Sample{Raw: 1 << 23}
+}
+
+// String implements conn.Resource
+func (d *dev) String() string { return d.name }
+
+// Reset implements measurement.Sensor
+func (d *dev) Reset(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.powerOn {
+		d.powerUp(time.Now())
+	}
+	return d.waitForReady(ctx)
+}
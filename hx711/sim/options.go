@@ -0,0 +1,69 @@
+package sim
+
+import (
+	"github.com/chewr/tension-scale/hx711"
+)
+
+type Option interface {
+	apply(d *dev)
+}
+
+type optFn func(d *dev)
+
+func (fn optFn) apply(d *dev) {
+	fn(d)
+}
+
+// WithSampleRate sets the conversion rate of the simulated device.
+// The default is 10SPS.
+func WithSampleRate(rate hx711.SampleRate) Option {
+	return optFn(func(d *dev) {
+		d.rate = rate
+	})
+}
+
+// WithOffset sets the raw reading of the unloaded cell
+func WithOffset(raw int32) Option {
+	return optFn(func(d *dev) {
+		d.offset = raw
+	})
+}
+
+// WithNoise adds gaussian noise with the given standard deviation,
+// in raw units, to every conversion
+func WithNoise(stddev float64) Option {
+	return optFn(func(d *dev) {
+		d.noise = stddev
+	})
+}
+
+// WithDrift adds a linear drift of the zero point, in raw units
+// per minute
+func WithDrift(perMinute float64) Option {
+	return optFn(func(d *dev) {
+		d.drift = perMinute
+	})
+}
+
+// WithGlitches causes each conversion to be read back as
+// hx711.ErrBadRead with the given probability
+func WithGlitches(probability float64) Option {
+	return optFn(func(d *dev) {
+		d.glitchRate = probability
+	})
+}
+
+// WithSeed seeds the random source used for noise and glitches so
+// that a simulation can be reproduced
+func WithSeed(seed int64) Option {
+	return optFn(func(d *dev) {
+		d.seed = seed
+	})
+}
+
+// WithName overrides the name reported by String
+func WithName(name string) Option {
+	return optFn(func(d *dev) {
+		d.name = name
+	})
+}
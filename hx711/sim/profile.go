@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"time"
+)
+
+// Profile describes the load on a simulated load cell over time,
+// expressed as the raw reading the HX711 would produce at a gain
+// of 128 with no offset, noise or drift applied
+type Profile interface {
+	Raw(elapsed time.Duration) int32
+}
+
+// ProfileFunc adapts an ordinary function to a Profile
+type ProfileFunc func(elapsed time.Duration) int32
+
+func (fn ProfileFunc) Raw(elapsed time.Duration) int32 {
+	return fn(elapsed)
+}
+
+// Constant returns a Profile which always reads the same value
+func Constant(raw int32) Profile {
+	return ProfileFunc(func(time.Duration) int32 { return raw })
+}
+
+// Segment is a linear ramp from one raw value to another over a
+// fixed duration. A Segment with From == To holds steady.
+type Segment struct {
+	Duration time.Duration
+	From, To int32
+}
+
+// Hold returns a Segment which holds a raw value steady
+func Hold(raw int32, d time.Duration) Segment {
+	return Segment{Duration: d, From: raw, To: raw}
+}
+
+// Ramp returns a Segment which changes linearly between two raw values
+func Ramp(from, to int32, d time.Duration) Segment {
+	return Segment{Duration: d, From: from, To: to}
+}
+
+func (s Segment) at(elapsed time.Duration) int32 {
+	if s.Duration <= 0 || elapsed >= s.Duration {
+		return s.To
+	}
+	delta := float64(s.To-s.From) * float64(elapsed) / float64(s.Duration)
+	return s.From + int32(delta)
+}
+
+type script struct {
+	segments []Segment
+	total    time.Duration
+	loop     bool
+}
+
+// Script returns a Profile which plays back segments in order. If
+// loop is set the script repeats indefinitely, otherwise the final
+// value is held once the script has finished.
+func Script(loop bool, segments ...Segment) Profile {
+	s := &script{
+		segments: segments,
+		loop:     loop,
+	}
+	for _, seg := range segments {
+		s.total += seg.Duration
+	}
+	return s
+}
+
+func (s *script) Raw(elapsed time.Duration) int32 {
+	if len(s.segments) == 0 {
+		return 0
+	}
+	if s.loop && s.total > 0 {
+		elapsed %= s.total
+	}
+	for _, seg := range s.segments {
+		if elapsed < seg.Duration {
+			return seg.at(elapsed)
+		}
+		elapsed -= seg.Duration
+	}
+	return s.segments[len(s.segments)-1].To
+}
+
+// RepeatedHang returns a looping Profile of an athlete stepping up
+// to the board, pulling to peak, holding for the hold duration,
+// releasing and resting for the rest duration
+func RepeatedHang(peak int32, hold, rest time.Duration) Profile {
+	const rampTime = 750 * time.Millisecond
+	return Script(true,
+		Hold(0, rest),
+		Ramp(0, peak, rampTime),
+		Hold(peak, hold),
+		Ramp(peak, 0, rampTime),
+	)
+}
@@ -0,0 +1,206 @@
+// Package analysis measures force over time in recordings of
+// workouts. Samples must be in time order. The rate of force
+// development of explosive pulls is measured by package rfd.
+package analysis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+var (
+	ErrNoSamples      = errors.New("no samples to analyze")
+	ErrTooShort       = errors.New("recording is shorter than the window to analyze")
+	ErrTooFewReps     = errors.New("fatigue index needs at least two reps")
+	ErrNoInitialForce = errors.New("no force in the first reps to compare with")
+)
+
+// newtons converts f to N, the unit of impulse calculations
+func newtons(f physic.Force) float64 {
+	return float64(f) / float64(physic.Newton)
+}
+
+// Peak returns the highest force sampled
+func Peak(samples []loadcell.ForceSample) (physic.Force, error) {
+	if len(samples) == 0 {
+		return 0, ErrNoSamples
+	}
+	peak := samples[0].Force
+	for _, s := range samples[1:] {
+		if s.Force > peak {
+			peak = s.Force
+		}
+	}
+	return peak, nil
+}
+
+// Impulse returns the integral of force over the time of the samples,
+// in N·s, interpolating linearly between samples. It is 0 for fewer
+// than two samples.
+func Impulse(samples []loadcell.ForceSample) float64 {
+	var impulse float64
+	for i := 1; i < len(samples); i++ {
+		impulse += trapezoid(samples[i-1], samples[i])
+	}
+	return impulse
+}
+
+// trapezoid returns the impulse between two successive samples, which
+// is 0 if they are out of order
+func trapezoid(a, b loadcell.ForceSample) float64 {
+	dt := b.Time.Sub(a.Time)
+	if dt <= 0 {
+		return 0
+	}
+	return dt.Seconds() * (newtons(a.Force) + newtons(b.Force)) / 2
+}
+
+// Mean returns the mean force over the time of the samples. Samples
+// all taken at the same time are averaged.
+func Mean(samples []loadcell.ForceSample) (physic.Force, error) {
+	if len(samples) == 0 {
+		return 0, ErrNoSamples
+	}
+	d := samples[len(samples)-1].Time.Sub(samples[0].Time)
+	if d <= 0 {
+		var sum float64
+		for _, s := range samples {
+			sum += float64(s.Force)
+		}
+		return physic.Force(sum / float64(len(samples))), nil
+	}
+	return physic.Force(Impulse(samples) / d.Seconds() * float64(physic.Newton)), nil
+}
+
+// TimeAbove returns how long the force was at or above threshold,
+// interpolating linearly where it crosses the threshold between
+// samples
+func TimeAbove(samples []loadcell.ForceSample, threshold physic.Force) time.Duration {
+	var above time.Duration
+	for i := 1; i < len(samples); i++ {
+		a, b := samples[i-1], samples[i]
+		dt := b.Time.Sub(a.Time)
+		if dt <= 0 {
+			continue
+		}
+		switch {
+		case a.Force >= threshold && b.Force >= threshold:
+			above += dt
+		case a.Force < threshold && b.Force < threshold:
+		default:
+			// the fraction of the interval on the far side of the
+			// crossing from the sample below
+			high, low := a.Force, b.Force
+			if high < low {
+				high, low = low, high
+			}
+			above += time.Duration(float64(dt) * float64(high-threshold) / float64(high-low))
+		}
+	}
+	return above
+}
+
+// PeakAverage returns the highest mean force over any span of samples
+// at least d long: the peak N-second average for a d of N seconds.
+// Spans are as short as the samples allow, so they are longer than d
+// only by up to the time between samples. It returns ErrTooShort if
+// the samples span less than d.
+func PeakAverage(samples []loadcell.ForceSample, d time.Duration) (physic.Force, error) {
+	if len(samples) == 0 {
+		return 0, ErrNoSamples
+	}
+	if d <= 0 {
+		return Peak(samples)
+	}
+	// cumulative[i] is the impulse up to sample i
+	cumulative := make([]float64, len(samples))
+	for i := 1; i < len(samples); i++ {
+		cumulative[i] = cumulative[i-1] + trapezoid(samples[i-1], samples[i])
+	}
+	best, found := 0.0, false
+	start := 0
+	for end, s := range samples {
+		for start+1 < end && s.Time.Sub(samples[start+1].Time) >= d {
+			start++
+		}
+		span := s.Time.Sub(samples[start].Time)
+		if span < d {
+			continue
+		}
+		if mean := (cumulative[end] - cumulative[start]) / span.Seconds(); !found || mean > best {
+			best, found = mean, true
+		}
+	}
+	if !found {
+		return 0, ErrTooShort
+	}
+	return physic.Force(best * float64(physic.Newton)), nil
+}
+
+// SustainedForce returns the highest force held throughout a span of
+// samples at least d long: the lowest force in the span, maximized
+// over spans. It takes linear time, keeping the candidates for the
+// lowest force in a span in a monotonic deque. It returns ErrTooShort
+// if the samples span less than d.
+func SustainedForce(samples []loadcell.ForceSample, d time.Duration) (physic.Force, error) {
+	if len(samples) == 0 {
+		return 0, ErrNoSamples
+	}
+	if d <= 0 {
+		return Peak(samples)
+	}
+	// deque holds indexes of samples in the span in time order, with
+	// increasing forces; its front is the lowest force in the span
+	deque := make([]int, 0, len(samples))
+	best, found := physic.Force(0), false
+	start := 0
+	for end, s := range samples {
+		for len(deque) > 0 && samples[deque[len(deque)-1]].Force >= s.Force {
+			deque = deque[:len(deque)-1]
+		}
+		deque = append(deque, end)
+		for start+1 < end && s.Time.Sub(samples[start+1].Time) >= d {
+			start++
+		}
+		for deque[0] < start {
+			deque = deque[1:]
+		}
+		if s.Time.Sub(samples[start].Time) < d {
+			continue
+		}
+		if f := samples[deque[0]].Force; !found || f > best {
+			best, found = f, true
+		}
+	}
+	if !found {
+		return 0, ErrTooShort
+	}
+	return best, nil
+}
+
+// FatigueIndex returns the fraction of force lost over a series of
+// reps, given the force of each rep such as its peak or mean: one
+// less the ratio of the mean of the last third of the reps to the mean
+// of the first third. It is 0 for no loss and may be negative if the
+// force rose.
+func FatigueIndex(reps []physic.Force) (float64, error) {
+	if len(reps) < 2 {
+		return 0, ErrTooFewReps
+	}
+	n := len(reps) / 3
+	if n == 0 {
+		n = 1
+	}
+	var first, last float64
+	for i := 0; i < n; i++ {
+		first += float64(reps[i])
+		last += float64(reps[len(reps)-n+i])
+	}
+	if first <= 0 {
+		return 0, ErrNoInitialForce
+	}
+	return 1 - last/first, nil
+}
@@ -0,0 +1,223 @@
+package analysis_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric/analysis"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// series returns samples of forces in N taken every step
+func series(step time.Duration, newtons ...float64) []loadcell.ForceSample {
+	samples := make([]loadcell.ForceSample, len(newtons))
+	for i, n := range newtons {
+		samples[i] = loadcell.ForceSample{
+			Force: physic.Force(n * float64(physic.Newton)),
+			Time:  start.Add(time.Duration(i) * step),
+		}
+	}
+	return samples
+}
+
+// windowed are the functions which measure force over a window
+var windowed = []struct {
+	name string
+	fn   func([]loadcell.ForceSample, time.Duration) (physic.Force, error)
+}{
+	{"PeakAverage", analysis.PeakAverage},
+	{"SustainedForce", analysis.SustainedForce},
+}
+
+func TestEmpty(t *testing.T) {
+	if _, err := analysis.Peak(nil); err != analysis.ErrNoSamples {
+		t.Errorf("Peak: got %v, want %v", err, analysis.ErrNoSamples)
+	}
+	if _, err := analysis.Mean(nil); err != analysis.ErrNoSamples {
+		t.Errorf("Mean: got %v, want %v", err, analysis.ErrNoSamples)
+	}
+	if i := analysis.Impulse(nil); i != 0 {
+		t.Errorf("Impulse: got %v, want 0", i)
+	}
+	if d := analysis.TimeAbove(nil, 0); d != 0 {
+		t.Errorf("TimeAbove: got %v, want 0", d)
+	}
+	for _, w := range windowed {
+		if _, err := w.fn(nil, time.Second); err != analysis.ErrNoSamples {
+			t.Errorf("%s: got %v, want %v", w.name, err, analysis.ErrNoSamples)
+		}
+	}
+}
+
+func TestSingleSample(t *testing.T) {
+	samples := series(0, 300)
+	want := samples[0].Force
+	if f, err := analysis.Peak(samples); err != nil || f != want {
+		t.Errorf("Peak = %v, %v, want %v", f, err, want)
+	}
+	if f, err := analysis.Mean(samples); err != nil || f != want {
+		t.Errorf("Mean = %v, %v, want %v", f, err, want)
+	}
+	if i := analysis.Impulse(samples); i != 0 {
+		t.Errorf("Impulse = %v, want 0", i)
+	}
+	if d := analysis.TimeAbove(samples, 0); d != 0 {
+		t.Errorf("TimeAbove = %v, want 0", d)
+	}
+	for _, w := range windowed {
+		if _, err := w.fn(samples, time.Millisecond); err != analysis.ErrTooShort {
+			t.Errorf("%s: got %v, want %v", w.name, err, analysis.ErrTooShort)
+		}
+		// without a window, the peak will do
+		if f, err := w.fn(samples, 0); err != nil || f != want {
+			t.Errorf("%s with no window = %v, %v, want %v", w.name, f, err, want)
+		}
+	}
+}
+
+func TestEqualTimestamps(t *testing.T) {
+	samples := series(0, 100, 300, 200)
+	if f, err := analysis.Mean(samples); err != nil || f != 200*physic.Newton {
+		t.Errorf("Mean = %v, %v, want the average, %v", f, err, 200*physic.Newton)
+	}
+	if f, err := analysis.Peak(samples); err != nil || f != 300*physic.Newton {
+		t.Errorf("Peak = %v, %v, want %v", f, err, 300*physic.Newton)
+	}
+	if i := analysis.Impulse(samples); i != 0 {
+		t.Errorf("Impulse = %v, want 0", i)
+	}
+	if d := analysis.TimeAbove(samples, 0); d != 0 {
+		t.Errorf("TimeAbove = %v, want 0", d)
+	}
+	for _, w := range windowed {
+		if _, err := w.fn(samples, time.Millisecond); err != analysis.ErrTooShort {
+			t.Errorf("%s: got %v, want %v", w.name, err, analysis.ErrTooShort)
+		}
+	}
+}
+
+// randomSamples returns n samples at irregular intervals, some of
+// them at the same time as the sample before
+func randomSamples(r *rand.Rand, n int) []loadcell.ForceSample {
+	samples := make([]loadcell.ForceSample, n)
+	t := start
+	for i := range samples {
+		if r.Intn(10) > 0 {
+			t = t.Add(time.Duration(r.Intn(30)) * time.Millisecond)
+		}
+		samples[i] = loadcell.ForceSample{
+			Force: physic.Force((r.Float64()*600 - 100) * float64(physic.Newton)),
+			Time:  t,
+		}
+	}
+	return samples
+}
+
+// shortestSpan returns the start of the shortest span of samples at
+// least d long which ends at end, or false if there is none
+func shortestSpan(samples []loadcell.ForceSample, end int, d time.Duration) (int, bool) {
+	for start := end - 1; start >= 0; start-- {
+		if samples[end].Time.Sub(samples[start].Time) >= d {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// bruteForce maximizes measure over the shortest span ending at each
+// sample
+func bruteForce(samples []loadcell.ForceSample, d time.Duration, measure func(span []loadcell.ForceSample) float64) (float64, error) {
+	best, found := 0.0, false
+	for end := range samples {
+		start, ok := shortestSpan(samples, end, d)
+		if !ok {
+			continue
+		}
+		if m := measure(samples[start : end+1]); !found || m > best {
+			best, found = m, true
+		}
+	}
+	if !found {
+		return 0, analysis.ErrTooShort
+	}
+	return best, nil
+}
+
+func mean(span []loadcell.ForceSample) float64 {
+	var impulse float64
+	for i := 1; i < len(span); i++ {
+		a, b := span[i-1], span[i]
+		impulse += b.Time.Sub(a.Time).Seconds() * float64(a.Force+b.Force) / 2
+	}
+	return impulse / span[len(span)-1].Time.Sub(span[0].Time).Seconds()
+}
+
+func lowest(span []loadcell.ForceSample) float64 {
+	low := span[0].Force
+	for _, s := range span[1:] {
+		if s.Force < low {
+			low = s.Force
+		}
+	}
+	return float64(low)
+}
+
+func TestWindowsAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		name    string
+		fn      func([]loadcell.ForceSample, time.Duration) (physic.Force, error)
+		measure func([]loadcell.ForceSample) float64
+	}{
+		{"PeakAverage", analysis.PeakAverage, mean},
+		{"SustainedForce", analysis.SustainedForce, lowest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for trial := 0; trial < 50; trial++ {
+				samples := randomSamples(r, 1+r.Intn(200))
+				for _, d := range []time.Duration{time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, time.Second, time.Minute} {
+					want, wantErr := bruteForce(samples, d, tc.measure)
+					got, err := tc.fn(samples, d)
+					if err != wantErr {
+						t.Fatalf("trial %d, %v window of %d samples: got error %v, want %v", trial, d, len(samples), err, wantErr)
+					}
+					// within a μN
+					if math.Abs(float64(got)-want) > 1000 {
+						t.Fatalf("trial %d, %v window of %d samples: got %v, want %.0fnN", trial, d, len(samples), got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFatigueIndex(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		reps  []physic.Force
+		index float64
+		err   error
+	}{
+		{"no reps", nil, 0, analysis.ErrTooFewReps},
+		{"one rep", []physic.Force{100 * physic.Newton}, 0, analysis.ErrTooFewReps},
+		{"two reps", []physic.Force{100 * physic.Newton, 80 * physic.Newton}, 0.2, nil},
+		{"two reps rising", []physic.Force{100 * physic.Newton, 110 * physic.Newton}, -0.1, nil},
+		{"no initial force", []physic.Force{0, 80 * physic.Newton}, 0, analysis.ErrNoInitialForce},
+		{"thirds", []physic.Force{100 * physic.Newton, 100 * physic.Newton, 90 * physic.Newton, 80 * physic.Newton, 50 * physic.Newton, 50 * physic.Newton}, 0.5, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := analysis.FatigueIndex(tc.reps)
+			if err != tc.err {
+				t.Fatalf("got error %v, want %v", err, tc.err)
+			}
+			if math.Abs(index-tc.index) > 1e-9 {
+				t.Errorf("got fatigue index %v, want %v", index, tc.index)
+			}
+		})
+	}
+}
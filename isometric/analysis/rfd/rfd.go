@@ -0,0 +1,188 @@
+// Package rfd measures the rate of force development of explosive
+// pulls: how quickly force rises from the onset of a contraction
+package rfd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	DefaultBaseline     = 500 * time.Millisecond
+	DefaultOnsetSD      = 3
+	DefaultOnsetMinimum = 5 * physic.Newton
+)
+
+var (
+	ErrNoBaseline = errors.New("not enough samples before the pull for a baseline")
+	ErrNoOnset    = errors.New("force never rose above the baseline")
+)
+
+// Windows are the standard windows from onset over which RFD is
+// reported
+var Windows = []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond}
+
+// Rate is a rate of force development in N/s
+type Rate float64
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%.0fN/s", float64(r))
+}
+
+// Metrics describes the rise in force of a single pull. Times are
+// measured from the onset.
+type Metrics struct {
+	// Baseline is the mean force before the pull
+	Baseline physic.Force `json:"baseline"`
+	// Onset is when the force first rose above the onset threshold
+	Onset time.Time `json:"onset"`
+	// RFD is the mean rate over each of Windows, or 0 where the
+	// recording ends before the window does
+	RFD []Rate `json:"rfd"`
+	// PeakRFD is the highest rate between successive samples, so
+	// depends on the sample rate
+	PeakRFD       Rate          `json:"peak-rfd"`
+	TimeToPeakRFD time.Duration `json:"time-to-peak-rfd"`
+	PeakForce     physic.Force  `json:"peak-force"`
+	TimeToPeak    time.Duration `json:"time-to-peak"`
+}
+
+type analysis struct {
+	baseline time.Duration
+	sd       float64
+	minimum  physic.Force
+}
+
+// Option configures Analyze
+type Option interface {
+	apply(a *analysis)
+}
+
+type optFn func(a *analysis)
+
+func (fn optFn) apply(a *analysis) {
+	fn(a)
+}
+
+// WithBaseline sets how much of the start of the recording, before
+// the pull, is used to find the baseline force and its noise
+func WithBaseline(d time.Duration) Option {
+	return optFn(func(a *analysis) {
+		a.baseline = d
+	})
+}
+
+// WithOnsetThreshold sets how far the force must rise over the
+// baseline for the pull to have started: the greater of sd standard
+// deviations of the baseline, and minimum
+func WithOnsetThreshold(sd float64, minimum physic.Force) Option {
+	return optFn(func(a *analysis) {
+		a.sd = sd
+		a.minimum = minimum
+	})
+}
+
+// Analyze finds the onset of a pull in samples, which are in time
+// order and start with the board quiet, and measures its rise
+func Analyze(samples []loadcell.ForceSample, opts ...Option) (Metrics, error) {
+	a := &analysis{
+		baseline: DefaultBaseline,
+		sd:       DefaultOnsetSD,
+		minimum:  DefaultOnsetMinimum,
+	}
+	for _, opt := range opts {
+		opt.apply(a)
+	}
+	if len(samples) == 0 {
+		return Metrics{}, ErrNoBaseline
+	}
+
+	// baseline
+	end := 0
+	var sum float64
+	for ; end < len(samples) && samples[end].Time.Sub(samples[0].Time) < a.baseline; end++ {
+		sum += float64(samples[end].Force)
+	}
+	if end < 2 || end == len(samples) {
+		return Metrics{}, ErrNoBaseline
+	}
+	mean := sum / float64(end)
+	var ss float64
+	for _, s := range samples[:end] {
+		ss += (float64(s.Force) - mean) * (float64(s.Force) - mean)
+	}
+	sd := math.Sqrt(ss / float64(end-1))
+	threshold := physic.Force(mean + math.Max(a.sd*sd, float64(a.minimum)))
+
+	// onset, interpolated between the samples either side of the
+	// threshold
+	onset := -1
+	for i := end; i < len(samples); i++ {
+		if samples[i].Force > threshold {
+			onset = i
+			break
+		}
+	}
+	if onset < 0 {
+		return Metrics{}, ErrNoOnset
+	}
+	prev := samples[onset-1]
+	frac := math.Max(0, float64(threshold-prev.Force)/float64(samples[onset].Force-prev.Force))
+	m := Metrics{
+		Baseline: physic.Force(mean),
+		Onset:    prev.Time.Add(time.Duration(frac * float64(samples[onset].Time.Sub(prev.Time)))),
+	}
+
+	f0, _ := forceAt(samples, m.Onset)
+	for _, w := range Windows {
+		var r Rate
+		if f, ok := forceAt(samples, m.Onset.Add(w)); ok {
+			r = Rate(float64(f-f0) / float64(physic.Newton) / w.Seconds())
+		}
+		m.RFD = append(m.RFD, r)
+	}
+
+	for i := onset; i < len(samples); i++ {
+		s := samples[i]
+		if s.Force > m.PeakForce {
+			m.PeakForce = s.Force
+			m.TimeToPeak = s.Time.Sub(m.Onset)
+		}
+		prev := samples[i-1]
+		dt := s.Time.Sub(prev.Time)
+		if dt <= 0 {
+			continue
+		}
+		if r := Rate(float64(s.Force-prev.Force) / float64(physic.Newton) / dt.Seconds()); r > m.PeakRFD {
+			m.PeakRFD = r
+			m.TimeToPeakRFD = prev.Time.Add(dt / 2).Sub(m.Onset)
+		}
+	}
+	if m.TimeToPeakRFD < 0 {
+		m.TimeToPeakRFD = 0
+	}
+	return m, nil
+}
+
+// forceAt interpolates the force at t, returning false if t is after
+// the last sample
+func forceAt(samples []loadcell.ForceSample, t time.Time) (physic.Force, bool) {
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Time.Before(t) {
+			continue
+		}
+		prev := samples[i-1]
+		dt := samples[i].Time.Sub(prev.Time)
+		if dt <= 0 {
+			return samples[i].Force, true
+		}
+		frac := float64(t.Sub(prev.Time)) / float64(dt)
+		return prev.Force + physic.Force(frac*float64(samples[i].Force-prev.Force)), true
+	}
+	return 0, false
+}
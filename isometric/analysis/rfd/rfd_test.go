@@ -0,0 +1,148 @@
+package rfd_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric/analysis/rfd"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	step = 5 * time.Millisecond
+	// quiet is how long the board is quiet before the pull
+	quiet = time.Second
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// pull returns samples, every step, of the board quiet at 20N and
+// then a pull lasting d, rising at 3000N/s for 100ms then at 1000N/s
+// for 200ms to 520N, which is held
+func pull(d time.Duration) []loadcell.ForceSample {
+	var samples []loadcell.ForceSample
+	for at := time.Duration(0); at < quiet+d; at += step {
+		n := 20.0
+		switch t := (at - quiet).Seconds(); {
+		case t < 0:
+		case t < 0.1:
+			n += 3000 * t
+		case t < 0.3:
+			n += 300 + 1000*(t-0.1)
+		default:
+			n = 520
+		}
+		samples = append(samples, loadcell.ForceSample{
+			Force: physic.Force(math.Round(n * float64(physic.Newton))),
+			Time:  start.Add(at),
+		})
+	}
+	return samples
+}
+
+func closeTo(got, want time.Duration) bool {
+	d := got - want
+	return d > -time.Microsecond && d < time.Microsecond
+}
+
+func TestAnalyze(t *testing.T) {
+	m, err := rfd.Analyze(pull(time.Second))
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if m.Baseline != 20*physic.Newton {
+		t.Errorf("got baseline %v, want %v", m.Baseline, 20*physic.Newton)
+	}
+	// 5N over the baseline, a third of the way to the first sample
+	// after the pull starts
+	onset := start.Add(quiet + 5*time.Millisecond/3)
+	if !closeTo(m.Onset.Sub(onset), 0) {
+		t.Errorf("got onset %v, want %v", m.Onset, onset)
+	}
+	// from 25N at the onset
+	want := []rfd.Rate{3000, (321.6667 - 25) / 0.1, (421.6667 - 25) / 0.2}
+	if len(m.RFD) != len(want) {
+		t.Fatalf("got RFD %v, want %v", m.RFD, want)
+	}
+	for i, r := range m.RFD {
+		if math.Abs(float64(r-want[i])) > 0.01 {
+			t.Errorf("got RFD over %v of %v, want %v", rfd.Windows[i], r, want[i])
+		}
+	}
+	if math.Abs(float64(m.PeakRFD-3000)) > 0.01 {
+		t.Errorf("got peak RFD %v, want %v", m.PeakRFD, rfd.Rate(3000))
+	}
+	// midway between the first two samples of the pull
+	if want := start.Add(quiet + step/2).Sub(onset); !closeTo(m.TimeToPeakRFD, want) {
+		t.Errorf("got time to peak RFD %v, want %v", m.TimeToPeakRFD, want)
+	}
+	if m.PeakForce != 520*physic.Newton {
+		t.Errorf("got peak force %v, want %v", m.PeakForce, 520*physic.Newton)
+	}
+	if want := start.Add(quiet + 300*time.Millisecond).Sub(onset); !closeTo(m.TimeToPeak, want) {
+		t.Errorf("got time to peak %v, want %v", m.TimeToPeak, want)
+	}
+}
+
+func TestAnalyzeShortRecording(t *testing.T) {
+	// ends between the 100ms and 200ms windows
+	m, err := rfd.Analyze(pull(150 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(m.RFD) != 3 || m.RFD[0] == 0 || m.RFD[1] == 0 || m.RFD[2] != 0 {
+		t.Errorf("got RFD %v, want none for the last window", m.RFD)
+	}
+}
+
+func TestAnalyzeOnsetThreshold(t *testing.T) {
+	m, err := rfd.Analyze(pull(time.Second), rfd.WithOnsetThreshold(0, 50*physic.Newton))
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if onset := start.Add(quiet + 50*time.Second/3000); !closeTo(m.Onset.Sub(onset), 0) {
+		t.Errorf("got onset %v, want %v", m.Onset, onset)
+	}
+
+	// noise of 2N, three standard deviations of which is more than
+	// the minimum
+	samples := pull(time.Second)
+	for i := range samples {
+		if samples[i].Time.Sub(start) < quiet && i%2 == 1 {
+			samples[i].Force += 4 * physic.Newton
+		}
+	}
+	m, err = rfd.Analyze(samples)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if m.Baseline != 22*physic.Newton {
+		t.Errorf("got baseline %v, want %v", m.Baseline, 22*physic.Newton)
+	}
+	if m.Onset.Sub(start) <= quiet+5*time.Millisecond/3 {
+		t.Errorf("got onset %v after the pull started, want later than for a quiet board", m.Onset.Sub(start.Add(quiet)))
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		samples []loadcell.ForceSample
+		opts    []rfd.Option
+		err     error
+	}{
+		{"no samples", nil, nil, rfd.ErrNoBaseline},
+		{"all in the baseline", pull(time.Second), []rfd.Option{rfd.WithBaseline(2 * time.Second)}, rfd.ErrNoBaseline},
+		{"single sample baseline", pull(time.Second), []rfd.Option{rfd.WithBaseline(step)}, rfd.ErrNoBaseline},
+		{"never rising", pull(0), nil, rfd.ErrNoOnset},
+		{"never crossing", pull(time.Second), []rfd.Option{rfd.WithOnsetThreshold(0, 600*physic.Newton)}, rfd.ErrNoOnset},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := rfd.Analyze(tc.samples, tc.opts...); err != tc.err {
+				t.Errorf("got error %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Package control lets a workout be paused, resumed, and have its
+// intervals skipped or repeated while it runs. Controls reach the
+// intervals of a workout through the context it is run with, so they
+// may be driven from any input source.
+package control
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRepeat is returned by an interval which was abandoned so that it
+// can be run again from the start. Composite workouts run it again.
+var ErrRepeat = errors.New("interval is to be repeated")
+
+// Event is a request to end the current interval early
+type Event int
+
+const (
+	None Event = iota
+	// Skip ends the current interval and moves on to the next
+	Skip
+	// Repeat restarts the current interval
+	Repeat
+)
+
+// Controls are the controls of a running workout. The zero value is
+// not paused. A nil *Controls is never paused and has no events, so
+// intervals may use the controls from any context.
+type Controls struct {
+	mu     sync.Mutex
+	paused bool
+	// pausedAt is when the current pause started
+	pausedAt time.Time
+	// pausedFor is the total length of past pauses
+	pausedFor time.Duration
+	// changed is closed and replaced whenever anything changes
+	changed chan struct{}
+	event   Event
+}
+
+// New returns controls for a workout, which is not paused
+func New() *Controls {
+	return &Controls{}
+}
+
+type contextKey struct{}
+
+// WithControls returns a context from which intervals find c
+func WithControls(ctx context.Context, c *Controls) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the controls of ctx, or nil if it has none
+func FromContext(ctx context.Context) *Controls {
+	c, _ := ctx.Value(contextKey{}).(*Controls)
+	return c
+}
+
+// notify wakes everything waiting for a change. It must be called
+// with c.mu held.
+func (c *Controls) notify() {
+	if c.changed != nil {
+		close(c.changed)
+		c.changed = nil
+	}
+}
+
+// wait returns a channel which is closed at the next change. It must
+// be called with c.mu held.
+func (c *Controls) wait() <-chan struct{} {
+	if c.changed == nil {
+		c.changed = make(chan struct{})
+	}
+	return c.changed
+}
+
+// Pause stops the clock of the current interval
+func (c *Controls) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return
+	}
+	c.paused = true
+	c.pausedAt = time.Now()
+	c.notify()
+}
+
+// Resume restarts the clock of the current interval
+func (c *Controls) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resumeLocked()
+}
+
+func (c *Controls) resumeLocked() {
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	c.pausedFor += time.Since(c.pausedAt)
+	c.notify()
+}
+
+// TogglePause pauses if running, and resumes if paused
+func (c *Controls) TogglePause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.resumeLocked()
+		return
+	}
+	c.paused = true
+	c.pausedAt = time.Now()
+	c.notify()
+}
+
+// Skip ends the current interval early. A paused workout is resumed.
+func (c *Controls) Skip() {
+	c.send(Skip)
+}
+
+// Repeat restarts the current interval. A paused workout is resumed.
+func (c *Controls) Repeat() {
+	c.send(Repeat)
+}
+
+func (c *Controls) send(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resumeLocked()
+	c.event = e
+	c.notify()
+}
+
+// Paused reports whether the workout is paused
+func (c *Controls) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// TakeEvent returns and clears the pending event, if any
+func (c *Controls) TakeEvent() Event {
+	if c == nil {
+		return None
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.event
+	c.event = None
+	return e
+}
+
+// clearEvent discards any event sent before an interval started, so
+// that it isn't applied to the wrong interval
+func (c *Controls) clearEvent() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.event = None
+}
+
+// Changed returns a channel which is closed when the workout is
+// paused or resumed, or an event is sent. A nil *Controls never
+// changes.
+func (c *Controls) Changed() <-chan struct{} {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.wait()
+}
+
+// WaitWhilePaused blocks until the workout is not paused
+func (c *Controls) WaitWhilePaused(ctx context.Context) error {
+	for {
+		if c == nil {
+			return nil
+		}
+		c.mu.Lock()
+		if !c.paused {
+			c.mu.Unlock()
+			return nil
+		}
+		changed := c.wait()
+		c.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// pauses returns the total time spent paused so far
+func (c *Controls) pauses() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d := c.pausedFor
+	if c.paused {
+		d += time.Since(c.pausedAt)
+	}
+	return d
+}
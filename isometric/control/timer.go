@@ -0,0 +1,97 @@
+package control
+
+import (
+	"context"
+	"time"
+)
+
+// Timer counts down the length of an interval, stopping while the
+// workout is paused
+type Timer struct {
+	c     *Controls
+	start time.Time
+	d     time.Duration
+	// paused is the total time paused when the timer started
+	paused time.Duration
+}
+
+// Timer starts a countdown of d for the current interval. Events sent
+// before it started are discarded.
+func (c *Controls) Timer(d time.Duration) *Timer {
+	return c.TimerFrom(time.Now(), d)
+}
+
+// TimerFrom starts a countdown of d from start, which may have just
+// passed, so that the phases of an interval timed by the clock can
+// follow each other without drifting. Events sent before it started
+// are discarded.
+func (c *Controls) TimerFrom(start time.Time, d time.Duration) *Timer {
+	t := &Timer{c: c, start: start, d: d}
+	if c != nil {
+		c.clearEvent()
+		t.paused = c.pauses()
+	}
+	return t
+}
+
+// Remaining returns how much of the countdown is left
+func (t *Timer) Remaining() time.Duration {
+	r := time.Until(t.End())
+	if r < 0 {
+		return 0
+	}
+	return r
+}
+
+// End returns when the countdown ends, or ended, allowing for the
+// pauses so far
+func (t *Timer) End() time.Time {
+	var paused time.Duration
+	if t.c != nil {
+		paused = t.c.pauses() - t.paused
+	}
+	return t.start.Add(t.d + paused)
+}
+
+// Deadline returns when the countdown will end if the workout is not
+// paused again
+func (t *Timer) Deadline() time.Time {
+	return time.Now().Add(t.Remaining())
+}
+
+// Wait blocks until the countdown ends, returning None, or until an
+// event is sent, returning the event. While paused, onPause is called
+// with the time remaining, and onResume is called on resuming.
+func (t *Timer) Wait(ctx context.Context, onPause func(remaining time.Duration) error, onResume func(deadline time.Time) error) (Event, error) {
+	for {
+		changed := t.c.Changed()
+		if e := t.c.TakeEvent(); e != None {
+			return e, nil
+		}
+		if t.c.Paused() {
+			if err := onPause(t.Remaining()); err != nil {
+				return None, err
+			}
+			if err := t.c.WaitWhilePaused(ctx); err != nil {
+				return None, err
+			}
+			if err := onResume(t.Deadline()); err != nil {
+				return None, err
+			}
+			continue
+		}
+		remaining := t.Remaining()
+		if remaining <= 0 {
+			return None, nil
+		}
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return None, ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package data
 
 import (
+	"bufio"
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,97 +18,284 @@ import (
 	"periph.io/x/periph/conn/physic"
 )
 
+const (
+	// flushInterval is how often samples are flushed to the partial
+	// file, bounding what is lost if the process dies
+	flushInterval = time.Second
+
+	partialSuffix = ".partial"
+
+	// csvTimeFormat is the format of the local time a recording
+	// started at which starts its file name
+	csvTimeFormat = "20060102150405"
+)
+
 type csvFileRecorder struct {
-	dir string
+	dir      string
+	metadata func() map[string]string
+}
+
+// CsvOption configures a CSV recorder
+type CsvOption interface {
+	apply(r *csvFileRecorder)
+}
+
+type csvOptFn func(r *csvFileRecorder)
+
+func (fn csvOptFn) apply(r *csvFileRecorder) {
+	fn(r)
+}
+
+// WithMetadata adds the metadata returned by fn to the header of the
+// recording. fn is called as each recording starts and again as it
+// completes, so values which change during the recording, such as
+// zero tracking adjustments, are up to date.
+func WithMetadata(fn func() map[string]string) CsvOption {
+	return csvOptFn(func(r *csvFileRecorder) {
+		r.metadata = fn
+	})
+}
+
+// CsvRecorder records each workout to a CSV file in dir. Samples are
+// streamed to a .partial file as they arrive, which is renamed into
+// place when the workout finishes or is closed, so a crash loses at
+// most the last second of samples and leaves the partial file behind.
+//
+// Each file starts with "# key: value" comment lines of metadata,
+// including the outcome, which is "aborted" if the workout was closed
+// without finishing. Times are nanoseconds from the first sample and
+// forces are in N.
+func CsvRecorder(dir string, opts ...CsvOption) (isometric.WorkoutRecorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	r := &csvFileRecorder{dir: dir}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return r, nil
+}
+
+func (r *csvFileRecorder) Start(_ context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
+	now := time.Now()
+	filename := fmt.Sprintf("%s-%s.csv", now.Format(csvTimeFormat), descriptor)
+	fpath := filepath.Join(r.dir, filename)
+
+	metadata := map[string]string{}
+	if r.metadata != nil {
+		for k, v := range r.metadata() {
+			metadata[k] = v
+		}
+	}
+	metadata["descriptor"] = descriptor
+	metadata["started"] = now.Format(time.RFC3339Nano)
+
+	f, err := os.OpenFile(fpath+partialSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	u := &csvFileRecorderUpdater{
+		filename: fpath,
+		partial:  f,
+		metadata: metadata,
+		refresh:  r.metadata,
+	}
+	if err := u.writeHeader(f, ""); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if u.bodyStart, err = f.Seek(0, io.SeekCurrent); err != nil {
+		f.Close()
+		return nil, err
+	}
+	u.w = csv.NewWriter(f)
+	u.lastFlush = now
+	return u, nil
 }
 
 type csvFileRecorderUpdater struct {
 	mu       sync.Mutex
 	filename string
-	samples  []loadcell.ForceSample
-	closed   bool
+	metadata map[string]string
+	refresh  func() map[string]string
+
+	partial *os.File
+	// bodyStart is the offset of the column headers in the partial
+	// file, after the metadata
+	bodyStart int64
+	w         *csv.Writer
+	lastFlush time.Time
+	// cells is the number of per-cell columns, set by the first sample
+	cells   int
+	start   time.Time
+	samples int
+	closed  bool
 }
 
-func (u *csvFileRecorderUpdater) Write(samples ...loadcell.ForceSample) error {
-	u.mu.Lock()
-	defer u.mu.Unlock()
-	if u.closed {
-		return isometric.ErrWriteAfterClosed
+// writeHeader writes the metadata, in a fixed order, and the outcome
+// if there is one
+func (u *csvFileRecorderUpdater) writeHeader(w io.Writer, outcome string) error {
+	keys := make([]string, 0, len(u.metadata))
+	for k := range u.metadata {
+		if k != "descriptor" && k != "started" {
+			keys = append(keys, k)
+		}
 	}
-	u.samples = append(u.samples, samples...)
-	return nil
+	sort.Strings(keys)
+	keys = append([]string{"descriptor", "started"}, keys...)
+	b := new(strings.Builder)
+	for _, k := range keys {
+		fmt.Fprintf(b, "# %s: %s\n", k, oneLine(u.metadata[k]))
+	}
+	if outcome != "" {
+		fmt.Fprintf(b, "# outcome: %s\n", oneLine(outcome))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
 }
 
-func (u *csvFileRecorderUpdater) Finish(_ isometric.WorkoutOutcome) error {
+func oneLine(s string) string {
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func (u *csvFileRecorderUpdater) Write(samples ...loadcell.ForceSample) error {
 	u.mu.Lock()
 	defer u.mu.Unlock()
 	if u.closed {
 		return isometric.ErrWriteAfterClosed
 	}
-
-	if len(u.samples) == 0 {
-		return isometric.ErrNoData
+	for _, s := range samples {
+		if u.samples == 0 {
+			if err := u.writeColumnHeaders(s); err != nil {
+				return err
+			}
+		}
+		entry := []string{
+			fmt.Sprintf("%d", s.Sub(u.start)), newtons(s.Force),
+		}
+		for i := 0; i < u.cells; i++ {
+			var f physic.Force
+			if i < len(s.Cells) {
+				f = s.Cells[i]
+			}
+			entry = append(entry, newtons(f))
+		}
+		if err := u.w.Write(entry); err != nil {
+			return err
+		}
+		u.samples++
 	}
-
-	f, err := os.OpenFile(u.filename, os.O_RDWR|os.O_CREATE, 0444)
-	if err != nil {
-		return err
+	if time.Since(u.lastFlush) >= flushInterval {
+		u.lastFlush = time.Now()
+		u.w.Flush()
+		return u.w.Error()
 	}
-	w := csv.NewWriter(f)
+	return nil
+}
 
+// writeColumnHeaders writes the column headers for samples like s.
+// Samples combined from several load cells get a column per cell.
+func (u *csvFileRecorderUpdater) writeColumnHeaders(s loadcell.ForceSample) error {
+	// we mainly care about duration from start
+	u.start = s.Time
+	u.cells = len(s.Cells)
 	columnHeaders := []string{
 		"time", "force",
 	}
-
-	// write column headers
-	if err := w.Write(columnHeaders); err != nil {
-		return err
+	for i := 0; i < u.cells; i++ {
+		columnHeaders = append(columnHeaders, fmt.Sprintf("cell%d", i))
 	}
+	return u.w.Write(columnHeaders)
+}
 
-	// ensure data is sorted to clean up our output
-	sort.Slice(u.samples, func(i, j int) bool {
-		return u.samples[i].Time.Before(u.samples[j].Time)
-	})
-
-	// we mainly care about duration from start
-	start := u.samples[0].Time
+func newtons(f physic.Force) string {
+	return fmt.Sprintf("%.3f", float64(f)/float64(physic.Newton))
+}
 
-	// write rest of data
-	for _, s := range u.samples {
-		entry := []string{
-			fmt.Sprintf("%d", s.Sub(start)), fmt.Sprintf("%d", s.Force/physic.Newton),
-		}
-		if err := w.Write(entry); err != nil {
-			return err
-		}
+func (u *csvFileRecorderUpdater) Finish(outcome isometric.WorkoutOutcome) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
 	}
-	w.Flush()
-	u.close()
-	return f.Close()
+	u.closed = true
+	if u.samples == 0 {
+		u.discard()
+		return isometric.ErrNoData
+	}
+	return u.complete(outcome.String())
 }
 
+// Close saves the recording as aborted if it hasn't finished
 func (u *csvFileRecorderUpdater) Close() {
 	u.mu.Lock()
 	defer u.mu.Unlock()
-	u.close()
+	if u.closed {
+		return
+	}
+	u.closed = true
+	if u.samples == 0 {
+		u.discard()
+		return
+	}
+	// there is nowhere to report an error, and the partial file is
+	// left behind if this fails
+	_ = u.complete(string(isometric.Aborted))
 }
 
-func (u *csvFileRecorderUpdater) close() {
-	u.closed = true
+func (u *csvFileRecorderUpdater) discard() {
+	u.partial.Close()
+	os.Remove(u.partial.Name())
 }
 
-func CsvRecorder(dir string) (isometric.WorkoutRecorder, error) {
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, err
+// complete writes the recording with its outcome to a temporary file
+// and renames it into place, then removes the partial file
+func (u *csvFileRecorderUpdater) complete(outcome string) error {
+	u.w.Flush()
+	if err := u.w.Error(); err != nil {
+		return err
+	}
+	if u.refresh != nil {
+		for k, v := range u.refresh() {
+			if k != "descriptor" && k != "started" {
+				u.metadata[k] = v
+			}
+		}
+	}
+	tmp, err := os.OpenFile(u.filename+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := u.copyWithOutcome(tmp, outcome); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), u.filename); err != nil {
+		return err
 	}
-	return &csvFileRecorder{dir: dir}, nil
+	u.discard()
+	return nil
 }
 
-func (r *csvFileRecorder) Start(_ context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
-	const timeFormat = "20060102150405"
-	filename := fmt.Sprintf("%s-%s.csv", time.Now().Format(timeFormat), descriptor)
-	fpath := filepath.Join(r.dir, filename)
-	return &csvFileRecorderUpdater{
-		filename: fpath,
-	}, nil
+func (u *csvFileRecorderUpdater) copyWithOutcome(tmp *os.File, outcome string) error {
+	bw := bufio.NewWriter(tmp)
+	if err := u.writeHeader(bw, outcome); err != nil {
+		return err
+	}
+	end, err := u.partial.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(bw, io.NewSectionReader(u.partial, u.bodyStart, end-u.bodyStart)); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return tmp.Sync()
 }
@@ -0,0 +1,204 @@
+package data_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/data"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// newtons returns a force of n N
+func newtons(n float64) physic.Force {
+	return physic.Force(n * float64(physic.Newton))
+}
+
+// closeTo is whether forces are within a μN, allowing for rounding
+// through the decimal newtons in the file
+func closeTo(a, b physic.Force) bool {
+	return math.Abs(float64(a-b)) <= float64(physic.MicroNewton)
+}
+
+// files returns the names of the files in dir
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// readOnly reads the only file in dir, which must be a recording
+func readOnly(t *testing.T, dir string) data.Recording {
+	t.Helper()
+	names := files(t, dir)
+	if len(names) != 1 || !strings.HasSuffix(names[0], ".csv") {
+		t.Fatalf("got files %v, want a single recording", names)
+	}
+	f, err := os.Open(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rec, err := data.ReadCsv(names[0], f)
+	if err != nil {
+		t.Fatalf("ReadCsv: %v", err)
+	}
+	return rec
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := data.CsvRecorder(dir, data.WithMetadata(func() map[string]string {
+		return map[string]string{"calibration": "truesun-400"}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := recorder.Start(context.Background(), "max-test-7s")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer u.Close()
+
+	start := time.Now()
+	want := []loadcell.ForceSample{
+		{Force: newtons(300.125), Cells: []physic.Force{newtons(150), newtons(150.125)}, Time: start},
+		{Force: newtons(450.5), Cells: []physic.Force{newtons(200.25), newtons(250.25)}, Time: start.Add(12500 * time.Microsecond)},
+		{Force: newtons(-1.5), Cells: []physic.Force{newtons(-1), newtons(-0.5)}, Time: start.Add(25 * time.Millisecond)},
+	}
+	if err := u.Write(want[:1]...); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := u.Write(want[1:]...); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	outcome := isometric.WorkoutOutcome{Result: isometric.Failure, BelowThreshold: 1500 * time.Millisecond}
+	if err := u.Finish(outcome); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	rec := readOnly(t, dir)
+	if rec.Descriptor != "max-test-7s" {
+		t.Errorf("got descriptor %q, want %q", rec.Descriptor, "max-test-7s")
+	}
+	if got := rec.Metadata["calibration"]; got != "truesun-400" {
+		t.Errorf("got calibration %q, want %q", got, "truesun-400")
+	}
+	if got, ok := rec.Outcome(); !ok || got != outcome {
+		t.Errorf("Outcome = %v, %v, want %v", got, ok, outcome)
+	}
+	if len(rec.Samples) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(rec.Samples), len(want))
+	}
+	for i, s := range rec.Samples {
+		// times are kept from the first sample
+		if got, w := s.Time.Sub(rec.Samples[0].Time), want[i].Time.Sub(start); got != w {
+			t.Errorf("sample %d at %v, want %v", i, got, w)
+		}
+		if !closeTo(s.Force, want[i].Force) {
+			t.Errorf("sample %d: got force %v, want %v", i, s.Force, want[i].Force)
+		}
+		if len(s.Cells) != len(want[i].Cells) {
+			t.Errorf("sample %d: got %d cells, want %d", i, len(s.Cells), len(want[i].Cells))
+			continue
+		}
+		for j, f := range s.Cells {
+			if !closeTo(f, want[i].Cells[j]) {
+				t.Errorf("sample %d: got cell %d force %v, want %v", i, j, f, want[i].Cells[j])
+			}
+		}
+	}
+
+	if err := u.Write(want...); err != isometric.ErrWriteAfterClosed {
+		t.Errorf("Write after Finish: got %v, want %v", err, isometric.ErrWriteAfterClosed)
+	}
+}
+
+func TestCloseWithoutFinish(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := data.CsvRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := recorder.Start(context.Background(), "static-10s-200N")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := u.Write(loadcell.ForceSample{Force: newtons(200), Time: time.Now()}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	u.Close()
+
+	rec := readOnly(t, dir)
+	if got, ok := rec.Outcome(); !ok || got.Result != isometric.Aborted {
+		t.Errorf("Outcome = %v, %v, want %v", got, ok, isometric.Aborted)
+	}
+	if len(rec.Samples) != 1 {
+		t.Errorf("got %d samples, want 1", len(rec.Samples))
+	}
+}
+
+func TestFinishWithoutSamples(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := data.CsvRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := recorder.Start(context.Background(), "static-10s-200N")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if names := files(t, dir); len(names) != 1 || !strings.HasSuffix(names[0], ".partial") {
+		t.Errorf("got files %v while recording, want a partial file", names)
+	}
+	if err := u.Finish(isometric.WorkoutOutcome{Result: isometric.Success}); err != isometric.ErrNoData {
+		t.Errorf("Finish: got %v, want %v", err, isometric.ErrNoData)
+	}
+	u.Close()
+	if names := files(t, dir); len(names) != 0 {
+		t.Errorf("got files %v, want none", names)
+	}
+}
+
+func TestReadBaselineFormat(t *testing.T) {
+	const name = "20200102030405-max-test-7s.csv"
+	rec, err := data.ReadCsv(name, strings.NewReader("time,force\n0,100\n100000000,250\n200000000,-3\n"))
+	if err != nil {
+		t.Fatalf("ReadCsv: %v", err)
+	}
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	if rec.Descriptor != "max-test-7s" || !rec.Start.Equal(start) {
+		t.Errorf("got %q started at %v, want %q started at %v", rec.Descriptor, rec.Start, "max-test-7s", start)
+	}
+	if _, ok := rec.Outcome(); ok {
+		t.Errorf("got an outcome, want none")
+	}
+	want := []physic.Force{100 * physic.Newton, 250 * physic.Newton, -3 * physic.Newton}
+	if len(rec.Samples) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(rec.Samples), len(want))
+	}
+	for i, s := range rec.Samples {
+		if s.Force != want[i] || len(s.Cells) != 0 {
+			t.Errorf("sample %d: got %v with cells %v, want %v", i, s.Force, s.Cells, want[i])
+		}
+		if at := start.Add(time.Duration(i) * 100 * time.Millisecond); !s.Time.Equal(at) {
+			t.Errorf("sample %d at %v, want %v", i, s.Time, at)
+		}
+	}
+	if !rec.End().Equal(start.Add(200 * time.Millisecond)) {
+		t.Errorf("End = %v, want %v", rec.End(), start.Add(200*time.Millisecond))
+	}
+}
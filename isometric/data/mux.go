@@ -3,6 +3,7 @@ package data
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/chewr/tension-scale/isometric"
 	"github.com/chewr/tension-scale/loadcell"
@@ -42,6 +43,18 @@ func (r *multiplexingRecorder) Start(ctx context.Context, name string) (_ isomet
 	}, nil
 }
 
+// Event implements isometric.EventRecorder
+func (r *multiplexingRecorder) Event(ctx context.Context, descriptor string, start, end time.Time, result isometric.WorkoutResult) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rc := range r.recorders {
+		if err := isometric.RecordEvent(ctx, rc, descriptor, start, end, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type multiplexingUpdater struct {
 	mu       sync.Mutex
 	updaters []isometric.WorkoutUpdater
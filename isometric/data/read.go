@@ -0,0 +1,161 @@
+package data
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+var ErrNotRecording = errors.New("not a workout recording")
+
+// Recording is a workout read back from a file written by CsvRecorder
+type Recording struct {
+	Descriptor string
+	Start      time.Time
+	// Metadata is empty for files recorded before metadata was kept
+	Metadata map[string]string
+	Samples  []loadcell.ForceSample
+}
+
+// Outcome returns the outcome of the recording, or false if it
+// wasn't recorded
+func (r Recording) Outcome() (isometric.WorkoutOutcome, bool) {
+	s, ok := r.Metadata["outcome"]
+	if !ok {
+		return isometric.WorkoutOutcome{}, false
+	}
+	return parseOutcome(s), true
+}
+
+// End returns the time of the last sample
+func (r Recording) End() time.Time {
+	if len(r.Samples) == 0 {
+		return r.Start
+	}
+	return r.Samples[len(r.Samples)-1].Time
+}
+
+// ParseCsvName returns the descriptor of a recording and the time it
+// started from its file name, or ErrNotRecording
+func ParseCsvName(name string) (string, time.Time, error) {
+	name = filepath.Base(name)
+	if !strings.HasSuffix(name, ".csv") || len(name) < len(csvTimeFormat)+len("-.csv") || name[len(csvTimeFormat)] != '-' {
+		return "", time.Time{}, ErrNotRecording
+	}
+	start, err := time.ParseInLocation(csvTimeFormat, name[:len(csvTimeFormat)], time.Local)
+	if err != nil {
+		return "", time.Time{}, ErrNotRecording
+	}
+	return strings.TrimSuffix(name[len(csvTimeFormat)+1:], ".csv"), start, nil
+}
+
+// ReadCsv reads the recording in the file with the given name. Files
+// recorded before metadata was kept, with whole newtons, can be read
+// too; their start comes from the file name, to the second.
+func ReadCsv(name string, r io.Reader) (Recording, error) {
+	descriptor, start, err := ParseCsvName(name)
+	if err != nil {
+		return Recording{}, err
+	}
+	rec := Recording{
+		Descriptor: descriptor,
+		Start:      start,
+		Metadata:   map[string]string{},
+	}
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return Recording{}, err
+		}
+		if b[0] != '#' {
+			break
+		}
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return Recording{}, err
+		}
+		kv := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(line), "#"), ":", 2)
+		if len(kv) != 2 {
+			return Recording{}, fmt.Errorf("bad metadata %q", line)
+		}
+		rec.Metadata[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	if s, ok := rec.Metadata["started"]; ok {
+		if rec.Start, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return Recording{}, fmt.Errorf("bad start: %v", err)
+		}
+	}
+	if d, ok := rec.Metadata["descriptor"]; ok {
+		rec.Descriptor = d
+	}
+
+	cr := csv.NewReader(br)
+	// per-cell columns are present only for combined load cells
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return Recording{}, err
+	}
+	if len(rows) == 0 {
+		return rec, nil
+	}
+	if len(rows[0]) < 2 || rows[0][0] != "time" || rows[0][1] != "force" {
+		return Recording{}, fmt.Errorf("bad column headers %v", rows[0])
+	}
+	for i, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			return Recording{}, fmt.Errorf("row %d has %d columns, want %d", i+2, len(row), len(rows[0]))
+		}
+		offset, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return Recording{}, fmt.Errorf("row %d: bad time: %v", i+2, err)
+		}
+		s := loadcell.ForceSample{Time: rec.Start.Add(time.Duration(offset))}
+		if s.Force, err = parseNewtons(row[1]); err != nil {
+			return Recording{}, fmt.Errorf("row %d: bad force: %v", i+2, err)
+		}
+		for _, cell := range row[2:] {
+			f, err := parseNewtons(cell)
+			if err != nil {
+				return Recording{}, fmt.Errorf("row %d: bad force: %v", i+2, err)
+			}
+			s.Cells = append(s.Cells, f)
+		}
+		rec.Samples = append(rec.Samples, s)
+	}
+	return rec, nil
+}
+
+func parseNewtons(s string) (physic.Force, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return physic.Force(n * float64(physic.Newton)), nil
+}
+
+// parseOutcome parses the outcome as written by
+// isometric.WorkoutOutcome.String
+func parseOutcome(s string) isometric.WorkoutOutcome {
+	fields := strings.SplitN(s, " ", 2)
+	o := isometric.WorkoutOutcome{Result: isometric.WorkoutResult(fields[0])}
+	const suffix = " below threshold)"
+	if len(fields) == 2 && strings.HasSuffix(fields[1], suffix) {
+		d := strings.TrimSuffix(strings.TrimPrefix(fields[1], "("), suffix)
+		o.BelowThreshold, _ = time.ParseDuration(d)
+	}
+	return o
+}
@@ -0,0 +1,145 @@
+// Package history keeps the results of past tests, so that workouts
+// can set thresholds relative to a recent max or critical force
+package history
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+var (
+	ErrNoMax           = errors.New("no max test found in history")
+	ErrNoCriticalForce = errors.New("no critical force test found in history")
+)
+
+// MaxResult is the result of a max test
+type MaxResult struct {
+	Time time.Time `json:"time"`
+	// Duration is how long the test required the force to be held
+	Duration time.Duration `json:"duration"`
+	// Force is the highest force held for the whole duration
+	Force physic.Force `json:"force"`
+	// Workout is the descriptor of the test
+	Workout string `json:"workout"`
+}
+
+func (r MaxResult) String() string {
+	return fmt.Sprintf("%v held for %v in %s on %s", r.Force, r.Duration, r.Workout, r.Time.Format("2006-01-02 15:04"))
+}
+
+// MaxStore stores max test results
+type MaxStore interface {
+	Record(r MaxResult) error
+	// Best returns the highest force among tests at least d long
+	// since the given time, or ErrNoMax if there are none
+	Best(d time.Duration, since time.Time) (MaxResult, error)
+}
+
+// FileStore stores max test results in a file as lines of JSON,
+// creating the file when the first result is recorded
+func FileStore(path string) MaxStore {
+	return &maxFileStore{jsonLines{path: path}}
+}
+
+type maxFileStore struct {
+	jsonLines
+}
+
+func (s *maxFileStore) Record(r MaxResult) error {
+	return s.append(r)
+}
+
+func (s *maxFileStore) Best(d time.Duration, since time.Time) (MaxResult, error) {
+	var best MaxResult
+	found := false
+	err := s.each(func(unmarshal func(v interface{}) error) error {
+		var r MaxResult
+		if err := unmarshal(&r); err != nil {
+			return err
+		}
+		if r.Duration < d || r.Time.Before(since) {
+			return nil
+		}
+		if !found || r.Force > best.Force {
+			best, found = r, true
+		}
+		return nil
+	})
+	if err != nil {
+		return MaxResult{}, err
+	}
+	if !found {
+		return MaxResult{}, ErrNoMax
+	}
+	return best, nil
+}
+
+// CriticalForceResult is the result of a critical force test
+type CriticalForceResult struct {
+	Time time.Time `json:"time"`
+	// CriticalForce is the highest force which can be sustained
+	// through intermittent contractions without exhaustion
+	CriticalForce physic.Force `json:"critical-force"`
+	// WPrime is the impulse in N·s which can be exerted above the
+	// critical force before exhaustion
+	WPrime float64 `json:"w-prime"`
+	// Reps are the mean forces of each contraction
+	Reps []physic.Force `json:"reps"`
+	// Workout is the descriptor of the test
+	Workout string `json:"workout"`
+}
+
+func (r CriticalForceResult) String() string {
+	return fmt.Sprintf("critical force %v, W' %.0fN·s in %s on %s", r.CriticalForce, r.WPrime, r.Workout, r.Time.Format("2006-01-02 15:04"))
+}
+
+// CriticalForceStore stores critical force test results
+type CriticalForceStore interface {
+	Record(r CriticalForceResult) error
+	// Latest returns the most recent test since the given time, or
+	// ErrNoCriticalForce if there are none
+	Latest(since time.Time) (CriticalForceResult, error)
+}
+
+// CriticalForceFileStore stores critical force test results in a file
+// as lines of JSON, creating the file when the first result is
+// recorded
+func CriticalForceFileStore(path string) CriticalForceStore {
+	return &criticalForceFileStore{jsonLines{path: path}}
+}
+
+type criticalForceFileStore struct {
+	jsonLines
+}
+
+func (s *criticalForceFileStore) Record(r CriticalForceResult) error {
+	return s.append(r)
+}
+
+func (s *criticalForceFileStore) Latest(since time.Time) (CriticalForceResult, error) {
+	var latest CriticalForceResult
+	found := false
+	err := s.each(func(unmarshal func(v interface{}) error) error {
+		var r CriticalForceResult
+		if err := unmarshal(&r); err != nil {
+			return err
+		}
+		if r.Time.Before(since) {
+			return nil
+		}
+		if !found || r.Time.After(latest.Time) {
+			latest, found = r, true
+		}
+		return nil
+	})
+	if err != nil {
+		return CriticalForceResult{}, err
+	}
+	if !found {
+		return CriticalForceResult{}, ErrNoCriticalForce
+	}
+	return latest, nil
+}
@@ -0,0 +1,55 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// jsonLines is a file of values encoded as lines of JSON
+type jsonLines struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (j *jsonLines) append(v interface{}) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// each calls fn for every line, which fn may decode with unmarshal.
+// A missing file has no lines.
+func (j *jsonLines) each(fn func(unmarshal func(v interface{}) error) error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if err := fn(func(v interface{}) error { return json.Unmarshal(line, v) }); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
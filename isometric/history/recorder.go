@@ -0,0 +1,94 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/analysis"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+type maxRecorder struct {
+	store MaxStore
+}
+
+// MaxRecorder records the result of every successful max test in
+// store. Other workouts are ignored.
+func MaxRecorder(store MaxStore) isometric.WorkoutRecorder {
+	return &maxRecorder{store: store}
+}
+
+func (r *maxRecorder) Start(_ context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
+	d, ok := interval.ParseMaxTest(descriptor)
+	if !ok {
+		return nopUpdater{}, nil
+	}
+	return &maxUpdater{
+		store:    r.store,
+		name:     descriptor,
+		duration: d,
+	}, nil
+}
+
+type maxUpdater struct {
+	store    MaxStore
+	name     string
+	duration time.Duration
+
+	mu      sync.Mutex
+	samples []loadcell.ForceSample
+	closed  bool
+}
+
+func (u *maxUpdater) Write(samples ...loadcell.ForceSample) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.samples = append(u.samples, samples...)
+	return nil
+}
+
+func (u *maxUpdater) Finish(outcome isometric.WorkoutOutcome) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.closed = true
+	if outcome.Result != isometric.Success || len(u.samples) == 0 {
+		return nil
+	}
+	sort.Slice(u.samples, func(i, j int) bool {
+		return u.samples[i].Time.Before(u.samples[j].Time)
+	})
+	f, err := analysis.SustainedForce(u.samples, u.duration)
+	if err == analysis.ErrTooShort || f <= 0 {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return u.store.Record(MaxResult{
+		Time:     u.samples[0].Time,
+		Duration: u.duration,
+		Force:    f,
+		Workout:  u.name,
+	})
+}
+
+func (u *maxUpdater) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closed = true
+}
+
+type nopUpdater struct{}
+
+func (nopUpdater) Write(...loadcell.ForceSample) error   { return nil }
+func (nopUpdater) Finish(isometric.WorkoutOutcome) error { return nil }
+func (nopUpdater) Close()                                {}
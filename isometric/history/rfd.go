@@ -0,0 +1,56 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric/analysis/rfd"
+)
+
+// RFDResult is the result of one pull of a rate of force development
+// test
+type RFDResult struct {
+	rfd.Metrics
+	// Workout is the descriptor of the pull
+	Workout string `json:"workout"`
+}
+
+func (r RFDResult) String() string {
+	return fmt.Sprintf("%s: peak %v after %v, peak RFD %v", r.Workout, r.PeakForce, r.TimeToPeak.Round(time.Millisecond), r.PeakRFD)
+}
+
+// RFDStore stores rate of force development results
+type RFDStore interface {
+	Record(r RFDResult) error
+	// Since returns every result since the given time, oldest first
+	Since(t time.Time) ([]RFDResult, error)
+}
+
+// RFDFileStore stores rate of force development results in a file as
+// lines of JSON, creating the file when the first result is recorded
+func RFDFileStore(path string) RFDStore {
+	return &rfdFileStore{jsonLines{path: path}}
+}
+
+type rfdFileStore struct {
+	jsonLines
+}
+
+func (s *rfdFileStore) Record(r RFDResult) error {
+	return s.append(r)
+}
+
+func (s *rfdFileStore) Since(t time.Time) ([]RFDResult, error) {
+	var results []RFDResult
+	err := s.each(func(unmarshal func(v interface{}) error) error {
+		var r RFDResult
+		if err := unmarshal(&r); err != nil {
+			return err
+		}
+		if !r.Onset.Before(t) {
+			results = append(results, r)
+		}
+		return nil
+	})
+	return results, err
+}
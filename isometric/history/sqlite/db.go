@@ -0,0 +1,183 @@
+// Package sqlite keeps the history of workouts in an SQLite database:
+// sessions, their intervals with outcomes and summary metrics, and the
+// samples of each interval. It can be queried for past sessions, the
+// best max for a duration and trends over a range of dates.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	// the driver needs cgo; without it opening a database fails
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const driver = "sqlite3"
+
+// migrations are applied in order to bring the schema up to date.
+// The number applied is kept in the database's user_version, so they
+// must never be changed once released, only added to.
+var migrations = []string{
+	`CREATE TABLE sessions (
+		id TEXT PRIMARY KEY,
+		protocol TEXT NOT NULL,
+		user TEXT NOT NULL DEFAULT '',
+		parameters TEXT NOT NULL DEFAULT '{}',
+		started INTEGER NOT NULL,
+		ended INTEGER
+	);
+	CREATE INDEX sessions_start ON sessions (started);
+	CREATE TABLE intervals (
+		session_id TEXT NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+		idx INTEGER NOT NULL,
+		descriptor TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		started INTEGER NOT NULL,
+		ended INTEGER,
+		outcome TEXT NOT NULL DEFAULT '',
+		below_threshold INTEGER NOT NULL DEFAULT 0,
+		samples INTEGER NOT NULL DEFAULT 0,
+		peak_force REAL,
+		mean_force REAL,
+		impulse REAL,
+		PRIMARY KEY (session_id, idx)
+	);
+	CREATE INDEX intervals_descriptor ON intervals (descriptor, started);
+	CREATE TABLE samples (
+		session_id TEXT NOT NULL,
+		idx INTEGER NOT NULL,
+		time INTEGER NOT NULL,
+		force REAL NOT NULL,
+		FOREIGN KEY (session_id, idx) REFERENCES intervals (session_id, idx) ON DELETE CASCADE
+	);
+	CREATE INDEX samples_interval ON samples (session_id, idx, time);
+	CREATE TABLE maxes (
+		time INTEGER NOT NULL,
+		duration INTEGER NOT NULL,
+		force REAL NOT NULL,
+		workout TEXT NOT NULL
+	);
+	CREATE INDEX maxes_duration ON maxes (duration, time);`,
+	`CREATE TABLE imports (
+		source TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		idx INTEGER NOT NULL,
+		FOREIGN KEY (session_id, idx) REFERENCES intervals (session_id, idx) ON DELETE CASCADE
+	);`,
+	// the same max may have been recorded more than once by imports
+	`DELETE FROM maxes WHERE rowid NOT IN (SELECT MIN(rowid) FROM maxes GROUP BY time, duration, workout);
+	CREATE UNIQUE INDEX maxes_unique ON maxes (time, duration, workout);`,
+}
+
+// DB is a history database
+type DB struct {
+	// Immutable.
+	db             *sql.DB
+	sampleInterval time.Duration
+	keepSamples    bool
+}
+
+// Option configures a database
+type Option interface {
+	apply(db *DB)
+}
+
+type optFn func(db *DB)
+
+func (fn optFn) apply(db *DB) {
+	fn(db)
+}
+
+// WithDownsampling keeps only samples at least d after the last
+// sample kept, rather than every sample
+func WithDownsampling(d time.Duration) Option {
+	return optFn(func(db *DB) {
+		db.sampleInterval = d
+	})
+}
+
+// WithoutSamples keeps only the summary of each interval
+func WithoutSamples() Option {
+	return optFn(func(db *DB) {
+		db.keepSamples = false
+	})
+}
+
+// Open opens the database at path, creating it if it doesn't exist
+// and migrating it to the current schema
+func Open(path string, opts ...Option) (*DB, error) {
+	sqlDB, err := sql.Open(driver, fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+	if err != nil {
+		return nil, err
+	}
+	// a single connection serializes writers, which sqlite requires
+	// anyway, and keeps the pragmas above in effect
+	sqlDB.SetMaxOpenConns(1)
+	db := &DB{db: sqlDB, keepSamples: true}
+	for _, opt := range opts {
+		opt.apply(db)
+	}
+	if err := db.migrate(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Close closes the database
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+func (db *DB) migrate() error {
+	var version int
+	if err := db.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating database to version %d: %v", i+1, err)
+		}
+		// pragmas can't take parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nanos converts t to the representation of times in the database
+func nanos(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	return time.Unix(0, n)
+}
+
+func nullNanos(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: nanos(t), Valid: true}
+}
+
+func fromNullNanos(n sql.NullInt64) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+	return fromNanos(n.Int64)
+}
@@ -0,0 +1,73 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"periph.io/x/periph/conn/physic"
+)
+
+var start = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// open opens a new database in a temporary directory
+func open(t *testing.T, opts ...sqlite.Option) *sqlite.DB {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "history.db"), opts...)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// sameMax is whether maxes are the same, wherever their times are
+func sameMax(a, b history.MaxResult) bool {
+	return a.Time.Equal(b.Time) && a.Duration == b.Duration && a.Force == b.Force && a.Workout == b.Workout
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	db, err := sqlite.Open(path)
+	if err != nil {
+		t.Fatalf("Open new database: %v", err)
+	}
+	max := history.MaxResult{Time: start, Duration: 7 * time.Second, Force: 500 * physic.Newton, Workout: "max-test-7s"}
+	if err := db.Record(max); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// the schema is up to date, so there is nothing to migrate
+	db, err = sqlite.Open(path)
+	if err != nil {
+		t.Fatalf("Open existing database: %v", err)
+	}
+	defer db.Close()
+	if got, err := db.Best(7*time.Second, start); err != nil || !sameMax(got, max) {
+		t.Errorf("Best = %v, %v, want %v", got, err, max)
+	}
+}
+
+func TestOpenNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	// the driver is registered by the sqlite package
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`PRAGMA user_version = 1000`); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	if db, err := sqlite.Open(path); err == nil {
+		db.Close()
+		t.Errorf("Open succeeded, want an error for a newer schema")
+	}
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+// ImportedInterval is a recorded interval read back from where it was
+// recorded, such as a CSV file
+type ImportedInterval struct {
+	// Index is ignored; intervals are stored in order of their start
+	session.Interval
+	Samples []loadcell.ForceSample
+}
+
+// importKey identifies an imported interval by what was recorded
+// rather than where it was read from, which may change between
+// imports
+func importKey(in ImportedInterval) string {
+	return fmt.Sprintf("%s@%d", in.Descriptor, nanos(in.Start))
+}
+
+// Import stores intervals in the session r, creating it if it doesn't
+// exist, and returns the indexes into intervals of those it stored.
+// Intervals already in the database, with the same descriptor and
+// start, are skipped, as is the whole session if it was recorded into
+// the database as it ran.
+func (db *DB) Import(r session.Record, intervals []ImportedInterval) ([]int, error) {
+	order := make([]int, len(intervals))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return intervals[order[i]].Start.Before(intervals[order[j]].Start)
+	})
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	imported, err := db.importIntervals(tx, r, intervals, order)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return imported, tx.Commit()
+}
+
+func (db *DB) importIntervals(tx *sql.Tx, r session.Record, intervals []ImportedInterval, order []int) ([]int, error) {
+	var existing, fromImports int
+	err := tx.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM sessions WHERE id = ?),
+		(SELECT COUNT(*) FROM imports WHERE session_id = ?)`,
+		r.ID, r.ID,
+	).Scan(&existing, &fromImports)
+	if err != nil {
+		return nil, err
+	}
+	if existing > 0 && fromImports == 0 {
+		return nil, nil
+	}
+	var fresh []int
+	for _, i := range order {
+		var seen int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM intervals WHERE descriptor = ? AND started = ?`,
+			intervals[i].Descriptor, nanos(intervals[i].Start),
+		).Scan(&seen)
+		if err != nil {
+			return nil, err
+		}
+		if seen == 0 {
+			fresh = append(fresh, i)
+		}
+	}
+	if len(fresh) == 0 {
+		// don't leave behind a session with nothing in it
+		return nil, nil
+	}
+	if existing > 0 && !r.End.IsZero() {
+		// the session may have grown since it was last imported
+		if _, err := tx.Exec(`UPDATE sessions SET ended = MAX(COALESCE(ended, 0), ?) WHERE id = ?`, nanos(r.End), r.ID); err != nil {
+			return nil, err
+		}
+	}
+	if existing == 0 {
+		params, err := marshalParameters(r.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO sessions (id, protocol, user, parameters, started, ended) VALUES (?, ?, ?, ?, ?, ?)`,
+			r.ID, r.Protocol, r.User, params, nanos(r.Start), nullNanos(r.End),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var next int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(idx) + 1, 0) FROM intervals WHERE session_id = ?`, r.ID).Scan(&next); err != nil {
+		return nil, err
+	}
+
+	for _, i := range fresh {
+		in := intervals[i]
+		s := summarize(in.Samples)
+		_, err := tx.Exec(
+			`INSERT INTO intervals (session_id, idx, descriptor, kind, started, ended, outcome, below_threshold, samples, peak_force, mean_force, impulse)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.ID, next, in.Descriptor, in.Kind, nanos(in.Start), nullNanos(in.End), string(in.Outcome), int64(in.BelowThreshold),
+			len(in.Samples), s.peak, s.mean, s.impulse,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.storeSamples(tx, r.ID, next, in.Samples); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO imports (source, session_id, idx) VALUES (?, ?, ?)`, importKey(in), r.ID, next); err != nil {
+			return nil, err
+		}
+		next++
+	}
+	return fresh, nil
+}
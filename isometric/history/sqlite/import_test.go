@@ -0,0 +1,81 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// hold returns samples of a hold of force starting at t, every 100ms
+// for d
+func hold(t time.Time, d time.Duration, force physic.Force) []loadcell.ForceSample {
+	var samples []loadcell.ForceSample
+	for at := time.Duration(0); at <= d; at += 100 * time.Millisecond {
+		samples = append(samples, loadcell.ForceSample{Force: force, Time: t.Add(at)})
+	}
+	return samples
+}
+
+func imported(descriptor string, t time.Time, d time.Duration, force physic.Force) sqlite.ImportedInterval {
+	return sqlite.ImportedInterval{
+		Interval: session.Interval{
+			Descriptor: descriptor,
+			Kind:       session.Recorded,
+			Start:      t,
+			End:        t.Add(d),
+			Outcome:    isometric.Success,
+		},
+		Samples: hold(t, d, force),
+	}
+}
+
+func TestImportTwice(t *testing.T) {
+	db := open(t)
+	intervals := []sqlite.ImportedInterval{
+		imported("max-test-7s", start.Add(time.Minute), 7*time.Second, 500*physic.Newton),
+		imported("max-test-7s", start, 7*time.Second, 450*physic.Newton),
+	}
+	record := session.Record{
+		Info:  session.Info{Protocol: "test"},
+		ID:    session.ID(start, "test"),
+		Start: start,
+		End:   start.Add(2 * time.Minute),
+	}
+
+	got, err := db.Import(record, intervals)
+	if err != nil {
+		t.Fatalf("first Import: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("first Import stored %v, want both intervals", got)
+	}
+	// imported again, as from a different directory, and grouped into
+	// a session of another name
+	if got, err := db.Import(record, intervals); err != nil || len(got) != 0 {
+		t.Errorf("second Import = %v, %v, want nothing stored", got, err)
+	}
+	record.ID = session.ID(start, "imported")
+	if got, err := db.Import(record, intervals); err != nil || len(got) != 0 {
+		t.Errorf("Import into another session = %v, %v, want nothing stored", got, err)
+	}
+
+	sessions, err := db.Sessions(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	if n := len(sessions[0].Intervals); n != 2 {
+		t.Errorf("got %d intervals, want 2", n)
+	}
+	// in order of their start
+	if iv := sessions[0].Intervals; len(iv) == 2 && !iv[0].Start.Equal(start) {
+		t.Errorf("first interval started at %v, want %v", iv[0].Start, start)
+	}
+}
@@ -0,0 +1,225 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Summary is the summary of a recorded interval
+type Summary struct {
+	SessionID string
+	session.Interval
+	// PeakForce, MeanForce and Impulse are zero if the interval has
+	// too few samples to compute them
+	PeakForce physic.Force
+	MeanForce physic.Force
+	// Impulse is the integral of force over the interval, in N·s
+	Impulse float64
+}
+
+// Sessions returns the sessions started in [from, to), in order, with
+// their intervals
+func (db *DB) Sessions(from, to time.Time) ([]session.Record, error) {
+	rows, err := db.db.Query(
+		`SELECT id, protocol, user, parameters, started, ended FROM sessions
+		WHERE started >= ? AND started < ? ORDER BY started`,
+		nanos(from), nanos(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var records []session.Record
+	for rows.Next() {
+		var (
+			r      session.Record
+			params string
+			start  int64
+			end    sql.NullInt64
+		)
+		if err := rows.Scan(&r.ID, &r.Protocol, &r.User, &params, &start, &end); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(params), &r.Parameters); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Start, r.End = fromNanos(start), fromNullNanos(end)
+		records = append(records, r)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	// intervals are read once the sessions are, since the database
+	// has a single connection
+	for i := range records {
+		summaries, err := db.summaries(`session_id = ?`, records[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range summaries {
+			records[i].Intervals = append(records[i].Intervals, s.Interval)
+		}
+	}
+	return records, nil
+}
+
+// Trend returns the summaries of the recorded intervals with
+// descriptors starting with prefix, such as "max-test-" for every max
+// test, which started in [from, to), in order
+func (db *DB) Trend(prefix string, from, to time.Time) ([]Summary, error) {
+	return db.summaries(
+		`kind = ? AND substr(descriptor, 1, ?) = ? AND started >= ? AND started < ? ORDER BY started`,
+		session.Recorded, len(prefix), prefix, nanos(from), nanos(to),
+	)
+}
+
+// Samples returns the samples kept for an interval of a session
+func (db *DB) Samples(sessionID string, index int) ([]loadcell.ForceSample, error) {
+	rows, err := db.db.Query(
+		`SELECT time, force FROM samples WHERE session_id = ? AND idx = ? ORDER BY time`,
+		sessionID, index,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var samples []loadcell.ForceSample
+	for rows.Next() {
+		var (
+			t     int64
+			force float64
+		)
+		if err := rows.Scan(&t, &force); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		samples = append(samples, loadcell.ForceSample{Time: fromNanos(t), Force: fromNewtons(force)})
+	}
+	return samples, closeRows(rows)
+}
+
+// summaries returns the summaries of the intervals matching where,
+// ordered by session and index unless where orders them
+func (db *DB) summaries(where string, args ...interface{}) ([]Summary, error) {
+	query := `SELECT session_id, idx, descriptor, kind, started, ended, outcome, below_threshold, samples, peak_force, mean_force, impulse
+		FROM intervals WHERE ` + where
+	if !strings.Contains(where, "ORDER BY") {
+		query += ` ORDER BY session_id, idx`
+	}
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var summaries []Summary
+	for rows.Next() {
+		var (
+			s                   Summary
+			start               int64
+			end                 sql.NullInt64
+			outcome             string
+			belowThreshold      int64
+			peak, mean, impulse sql.NullFloat64
+		)
+		err := rows.Scan(&s.SessionID, &s.Index, &s.Descriptor, &s.Kind, &start, &end, &outcome, &belowThreshold, &s.Samples, &peak, &mean, &impulse)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Start, s.End = fromNanos(start), fromNullNanos(end)
+		s.Outcome = isometric.WorkoutResult(outcome)
+		s.BelowThreshold = time.Duration(belowThreshold)
+		s.PeakForce = fromNewtons(peak.Float64)
+		s.MeanForce = fromNewtons(mean.Float64)
+		s.Impulse = impulse.Float64
+		summaries = append(summaries, s)
+	}
+	return summaries, closeRows(rows)
+}
+
+// Record implements history.MaxStore. A max already recorded, for the
+// same workout at the same time, is ignored.
+func (db *DB) Record(r history.MaxResult) error {
+	_, err := db.db.Exec(
+		`INSERT OR IGNORE INTO maxes (time, duration, force, workout) VALUES (?, ?, ?, ?)`,
+		nanos(r.Time), int64(r.Duration), newtons(r.Force), r.Workout,
+	)
+	return err
+}
+
+// Best implements history.MaxStore
+func (db *DB) Best(d time.Duration, since time.Time) (history.MaxResult, error) {
+	var (
+		r      history.MaxResult
+		t, dur int64
+		force  float64
+	)
+	err := db.db.QueryRow(
+		`SELECT time, duration, force, workout FROM maxes WHERE duration >= ? AND time >= ?
+		ORDER BY force DESC, time DESC LIMIT 1`,
+		int64(d), nanos(since),
+	).Scan(&t, &dur, &force, &r.Workout)
+	if err == sql.ErrNoRows {
+		return history.MaxResult{}, history.ErrNoMax
+	} else if err != nil {
+		return history.MaxResult{}, err
+	}
+	r.Time, r.Duration, r.Force = fromNanos(t), time.Duration(dur), fromNewtons(force)
+	return r, nil
+}
+
+// MaxTrend returns the best max for tests at least d long on each
+// day in [from, to) with one, in order
+func (db *DB) MaxTrend(d time.Duration, from, to time.Time) ([]history.MaxResult, error) {
+	rows, err := db.db.Query(
+		`SELECT time, duration, force, workout FROM maxes WHERE duration >= ? AND time >= ? AND time < ? ORDER BY time`,
+		int64(d), nanos(from), nanos(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var trend []history.MaxResult
+	for rows.Next() {
+		var (
+			r      history.MaxResult
+			t, dur int64
+			force  float64
+		)
+		if err := rows.Scan(&t, &dur, &force, &r.Workout); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Time, r.Duration, r.Force = fromNanos(t), time.Duration(dur), fromNewtons(force)
+		// days are local to where the history is read
+		if n := len(trend); n > 0 && sameDay(trend[n-1].Time, r.Time) {
+			if r.Force > trend[n-1].Force {
+				trend[n-1] = r
+			}
+			continue
+		}
+		trend = append(trend, r)
+	}
+	return trend, closeRows(rows)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// closeRows closes rows, returning any error from iterating them
+func closeRows(rows *sql.Rows) error {
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	return rows.Close()
+}
@@ -0,0 +1,116 @@
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"github.com/chewr/tension-scale/isometric/session"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestSessions(t *testing.T) {
+	db := open(t)
+	for i, protocol := range []string{"test", "max-hang", "repeaters"} {
+		at := start.Add(time.Duration(i) * 24 * time.Hour)
+		record := session.Record{
+			Info:  session.Info{Protocol: protocol},
+			ID:    session.ID(at, protocol),
+			Start: at,
+			End:   at.Add(time.Hour),
+		}
+		in := imported("max-test-7s", at, 7*time.Second, 400*physic.Newton)
+		if i == 1 {
+			in = imported("static-10s-200N", at, 10*time.Second, 250*physic.Newton)
+		}
+		if _, err := db.Import(record, []sqlite.ImportedInterval{in}); err != nil {
+			t.Fatalf("Import: %v", err)
+		}
+	}
+
+	sessions, err := db.Sessions(start.Add(time.Hour), start.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Protocol != "max-hang" || sessions[1].Protocol != "repeaters" {
+		t.Fatalf("got sessions %+v, want those after the first, in order", sessions)
+	}
+	if len(sessions[0].Intervals) != 1 || sessions[0].Intervals[0].Descriptor != "static-10s-200N" {
+		t.Errorf("got intervals %+v", sessions[0].Intervals)
+	}
+
+	trend, err := db.Trend("max-test-", start, start.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("Trend: %v", err)
+	}
+	if len(trend) != 2 || !trend[0].Start.Equal(start) || !trend[1].Start.Equal(start.Add(48*time.Hour)) {
+		t.Fatalf("got trend %+v, want the two max tests in order", trend)
+	}
+	s := trend[0]
+	if s.SessionID != session.ID(start, "test") || s.PeakForce != 400*physic.Newton || s.MeanForce != 400*physic.Newton {
+		t.Errorf("got summary %+v", s)
+	}
+	// 400N for 7s
+	if s.Impulse < 2799.999 || s.Impulse > 2800.001 {
+		t.Errorf("got impulse %vN·s, want 2800N·s", s.Impulse)
+	}
+	if trend, err := db.Trend("max-test-", start.Add(time.Hour), start.Add(48*time.Hour)); err != nil || len(trend) != 0 {
+		t.Errorf("Trend between the max tests = %+v, %v, want none", trend, err)
+	}
+}
+
+func TestMaxes(t *testing.T) {
+	db := open(t)
+	// days are those where the history is read
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.Local)
+	if _, err := db.Best(7*time.Second, start); err != history.ErrNoMax {
+		t.Errorf("Best of no maxes: got %v, want %v", err, history.ErrNoMax)
+	}
+
+	maxes := []history.MaxResult{
+		{Time: start, Duration: 7 * time.Second, Force: 500 * physic.Newton, Workout: "max-test-7s"},
+		{Time: start.Add(time.Hour), Duration: 7 * time.Second, Force: 520 * physic.Newton, Workout: "max-test-7s"},
+		{Time: start.Add(24 * time.Hour), Duration: 7 * time.Second, Force: 510 * physic.Newton, Workout: "max-test-7s"},
+		{Time: start.Add(25 * time.Hour), Duration: 10 * time.Second, Force: 480 * physic.Newton, Workout: "max-test-10s"},
+		{Time: start.Add(26 * time.Hour), Duration: 5 * time.Second, Force: 600 * physic.Newton, Workout: "max-test-5s"},
+	}
+	for _, m := range maxes {
+		if err := db.Record(m); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	// recorded again, as by an import, which is ignored
+	if err := db.Record(maxes[1]); err != nil {
+		t.Fatalf("Record again: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		d     time.Duration
+		since time.Time
+		want  history.MaxResult
+	}{
+		{"best", 7 * time.Second, start, maxes[1]},
+		{"since", 7 * time.Second, start.Add(2 * time.Hour), maxes[2]},
+		{"longer tests count", 10 * time.Second, start, maxes[3]},
+		{"shorter tests count", 5 * time.Second, start, maxes[4]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := db.Best(tc.d, tc.since); err != nil || !sameMax(got, tc.want) {
+				t.Errorf("Best = %+v, %v, want %+v", got, err, tc.want)
+			}
+		})
+	}
+	if _, err := db.Best(time.Minute, start); err != history.ErrNoMax {
+		t.Errorf("Best of longer tests: got %v, want %v", err, history.ErrNoMax)
+	}
+
+	trend, err := db.MaxTrend(7*time.Second, start.Add(-time.Hour), start.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("MaxTrend: %v", err)
+	}
+	if len(trend) != 2 || !sameMax(trend[0], maxes[1]) || !sameMax(trend[1], maxes[2]) {
+		t.Errorf("got trend %+v, want the best of each day, %+v and %+v", trend, maxes[1], maxes[2])
+	}
+}
@@ -0,0 +1,235 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/analysis"
+	"github.com/chewr/tension-scale/isometric/session"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Recorder records a session in the database. Each interval is
+// stored as it starts, so the order of the session is kept, and its
+// outcome, summary and samples are stored when it finishes.
+type Recorder struct {
+	// Immutable.
+	db *DB
+	id string
+
+	// Mutable.
+	mu   sync.Mutex
+	next int
+}
+
+// Session starts recording the session id in the database
+func (db *DB) Session(id string, info session.Info, start time.Time) (*Recorder, error) {
+	params, err := marshalParameters(info.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.db.Exec(
+		`INSERT INTO sessions (id, protocol, user, parameters, started) VALUES (?, ?, ?, ?, ?)`,
+		id, info.Protocol, info.User, params, nanos(start),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{db: db, id: id}, nil
+}
+
+func marshalParameters(params map[string]string) (string, error) {
+	if params == nil {
+		params = map[string]string{}
+	}
+	b, err := json.Marshal(params)
+	return string(b), err
+}
+
+// index returns the index of the next interval of the session
+func (r *Recorder) index() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.next
+	r.next++
+	return i
+}
+
+// Start implements isometric.WorkoutRecorder
+func (r *Recorder) Start(ctx context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
+	index := r.index()
+	_, err := r.db.db.ExecContext(ctx,
+		`INSERT INTO intervals (session_id, idx, descriptor, kind, started) VALUES (?, ?, ?, ?, ?)`,
+		r.id, index, descriptor, session.Recorded, nanos(time.Now()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &updater{recorder: r, index: index}, nil
+}
+
+// Event implements isometric.EventRecorder
+func (r *Recorder) Event(ctx context.Context, descriptor string, start, end time.Time, result isometric.WorkoutResult) error {
+	_, err := r.db.db.ExecContext(ctx,
+		`INSERT INTO intervals (session_id, idx, descriptor, kind, started, ended, outcome) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.id, r.index(), descriptor, session.Event, nanos(start), nanos(end), string(result),
+	)
+	return err
+}
+
+// End marks the session as ended. Intervals which haven't finished
+// are marked aborted.
+func (r *Recorder) End() error {
+	end := nanos(time.Now())
+	tx, err := r.db.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE sessions SET ended = ? WHERE id = ?`, end, r.id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(
+		`UPDATE intervals SET ended = ?, outcome = ? WHERE session_id = ? AND kind = ? AND outcome = ''`,
+		end, string(isometric.Aborted), r.id, session.Recorded,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+type updater struct {
+	recorder *Recorder
+	index    int
+
+	mu      sync.Mutex
+	samples []loadcell.ForceSample
+	closed  bool
+}
+
+func (u *updater) Write(samples ...loadcell.ForceSample) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.samples = append(u.samples, samples...)
+	return nil
+}
+
+func (u *updater) Finish(outcome isometric.WorkoutOutcome) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.closed = true
+	return u.store(outcome)
+}
+
+func (u *updater) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return
+	}
+	u.closed = true
+	// there is nowhere to report an error; the session's end marks
+	// the interval aborted if this fails
+	_ = u.store(isometric.WorkoutOutcome{Result: isometric.Aborted})
+}
+
+// store stores the outcome, summary and samples of the interval in
+// one transaction
+func (u *updater) store(outcome isometric.WorkoutOutcome) error {
+	sort.Slice(u.samples, func(i, j int) bool {
+		return u.samples[i].Time.Before(u.samples[j].Time)
+	})
+	db := u.recorder.db
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	s := summarize(u.samples)
+	_, err = tx.Exec(
+		`UPDATE intervals SET ended = ?, outcome = ?, below_threshold = ?, samples = ?, peak_force = ?, mean_force = ?, impulse = ?
+		WHERE session_id = ? AND idx = ?`,
+		nanos(time.Now()), string(outcome.Result), int64(outcome.BelowThreshold), len(u.samples), s.peak, s.mean, s.impulse,
+		u.recorder.id, u.index,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := db.storeSamples(tx, u.recorder.id, u.index, u.samples); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// storeSamples stores the samples of an interval, as configured, in
+// order
+func (db *DB) storeSamples(tx *sql.Tx, sessionID string, index int, samples []loadcell.ForceSample) error {
+	if !db.keepSamples {
+		return nil
+	}
+	stmt, err := tx.Prepare(`INSERT INTO samples (session_id, idx, time, force) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	var last time.Time
+	for i, sample := range samples {
+		if i > 0 && sample.Time.Sub(last) < db.sampleInterval {
+			continue
+		}
+		last = sample.Time
+		if _, err := stmt.Exec(sessionID, index, nanos(sample.Time), newtons(sample.Force)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summary holds the summary metrics of an interval, in N and N·s,
+// which are null without enough samples to compute them
+type summary struct {
+	peak, mean, impulse *float64
+}
+
+func summarize(samples []loadcell.ForceSample) summary {
+	var s summary
+	peak, err := analysis.Peak(samples)
+	if err != nil {
+		return s
+	}
+	s.peak = float(newtons(peak))
+	if samples[len(samples)-1].Time.Sub(samples[0].Time) <= 0 {
+		return s
+	}
+	mean, _ := analysis.Mean(samples)
+	s.mean = float(newtons(mean))
+	s.impulse = float(analysis.Impulse(samples))
+	return s
+}
+
+func newtons(f physic.Force) float64 {
+	return float64(f) / float64(physic.Newton)
+}
+
+func fromNewtons(n float64) physic.Force {
+	return physic.Force(n * float64(physic.Newton))
+}
+
+func float(f float64) *float64 {
+	return &f
+}
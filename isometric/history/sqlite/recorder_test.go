@@ -0,0 +1,153 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/history/sqlite"
+	"github.com/chewr/tension-scale/isometric/session"
+	"periph.io/x/periph/conn/physic"
+)
+
+// record records a session of a finished hold, one closed without
+// finishing, a rest and one left running as the session ends
+func record(t *testing.T, db *sqlite.DB, id string) {
+	t.Helper()
+	ctx := context.Background()
+	r, err := db.Session(id, session.Info{Protocol: "max-hang", User: "alex", Parameters: map[string]string{"hold": "10s"}}, time.Now())
+	if err != nil {
+		t.Fatalf("Session: %v", err)
+	}
+
+	u, err := r.Start(ctx, "static-10s-200N")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	now := time.Now()
+	// written out of order, which is how they are stored
+	if err := u.Write(hold(now.Add(500*time.Millisecond), 500*time.Millisecond, 300*physic.Newton)...); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := u.Write(hold(now, 400*time.Millisecond, 200*physic.Newton)...); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := u.Finish(isometric.WorkoutOutcome{Result: isometric.Success, BelowThreshold: 200 * time.Millisecond}); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if err := u.Write(hold(now, 0, 0)...); err != isometric.ErrWriteAfterClosed {
+		t.Errorf("Write after Finish: got %v, want %v", err, isometric.ErrWriteAfterClosed)
+	}
+	u.Close()
+
+	u, err = r.Start(ctx, "static-10s-200N")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := u.Write(hold(time.Now(), 200*time.Millisecond, 100*physic.Newton)...); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	u.Close()
+
+	if err := r.Event(ctx, "rest-3m0s", time.Now(), time.Now().Add(3*time.Minute), isometric.Skipped); err != nil {
+		t.Fatalf("Event: %v", err)
+	}
+	if _, err := r.Start(ctx, "static-10s-200N"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := r.End(); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	db := open(t)
+	record(t, db, "session")
+
+	sessions, err := db.Sessions(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	s := sessions[0]
+	if s.ID != "session" || s.Protocol != "max-hang" || s.User != "alex" || s.Parameters["hold"] != "10s" || s.End.IsZero() {
+		t.Errorf("got session %+v", s)
+	}
+	want := []struct {
+		descriptor string
+		kind       string
+		outcome    isometric.WorkoutResult
+	}{
+		{"static-10s-200N", session.Recorded, isometric.Success},
+		{"static-10s-200N", session.Recorded, isometric.Aborted},
+		{"rest-3m0s", session.Event, isometric.Skipped},
+		// marked aborted as the session ended
+		{"static-10s-200N", session.Recorded, isometric.Aborted},
+	}
+	if len(s.Intervals) != len(want) {
+		t.Fatalf("got %d intervals, want %d", len(s.Intervals), len(want))
+	}
+	for i, in := range s.Intervals {
+		if in.Index != i || in.Descriptor != want[i].descriptor || in.Kind != want[i].kind || in.Outcome != want[i].outcome || in.End.IsZero() {
+			t.Errorf("interval %d: got %+v, want %+v", i, in, want[i])
+		}
+	}
+	if d := s.Intervals[0].BelowThreshold; d != 200*time.Millisecond {
+		t.Errorf("got %v below threshold, want %v", d, 200*time.Millisecond)
+	}
+
+	samples, err := db.Samples("session", 0)
+	if err != nil {
+		t.Fatalf("Samples: %v", err)
+	}
+	if len(samples) != 11 {
+		t.Fatalf("got %d samples, want 11", len(samples))
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Time.Before(samples[i-1].Time) {
+			t.Fatalf("sample %d out of order", i)
+		}
+	}
+	if samples[0].Force != 200*physic.Newton || samples[len(samples)-1].Force != 300*physic.Newton {
+		t.Errorf("got samples from %v to %v, want from %v to %v", samples[0].Force, samples[len(samples)-1].Force, 200*physic.Newton, 300*physic.Newton)
+	}
+}
+
+func TestRecorderSamples(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		opts    []sqlite.Option
+		samples int
+	}{
+		{"every sample", nil, 11},
+		{"downsampled", []sqlite.Option{sqlite.WithDownsampling(200 * time.Millisecond)}, 6},
+		{"without samples", []sqlite.Option{sqlite.WithoutSamples()}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := open(t, tc.opts...)
+			record(t, db, "session")
+			samples, err := db.Samples("session", 0)
+			if err != nil {
+				t.Fatalf("Samples: %v", err)
+			}
+			if len(samples) != tc.samples {
+				t.Errorf("got %d samples, want %d", len(samples), tc.samples)
+			}
+			// the summary is kept either way
+			sessions, err := db.Sessions(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("Sessions: %v", err)
+			}
+			trend, err := db.Trend("static-", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("Trend: %v", err)
+			}
+			if len(sessions) != 1 || len(trend) != 3 || trend[0].Samples != 11 || trend[0].PeakForce != 300*physic.Newton {
+				t.Errorf("got %d sessions and summaries %+v", len(sessions), trend)
+			}
+		})
+	}
+}
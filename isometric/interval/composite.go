@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/state"
 	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
 	"github.com/chewr/tension-scale/loadcell"
 )
 
@@ -21,13 +23,26 @@ func (c composite) String() string {
 }
 
 func (c composite) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
-	for _, w := range c {
+	controls := control.FromContext(ctx)
+	for i := 0; i < len(c); i++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
-		if err := w.Run(ctx, model, loadCell, recorder); err != nil {
+		if controls.Paused() {
+			if err := model.UpdateState(state.Paused(0)); err != nil {
+				return err
+			}
+			if err := controls.WaitWhilePaused(ctx); err != nil {
+				return err
+			}
+		}
+		switch err := c[i].Run(ctx, model, loadCell, recorder); err {
+		case nil:
+		case control.ErrRepeat:
+			i--
+		default:
 			return err
 		}
 	}
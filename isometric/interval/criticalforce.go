@@ -0,0 +1,145 @@
+package interval
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/input"
+	"github.com/chewr/tension-scale/display/state"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+const criticalForcePrefix = "critical-force-"
+
+type criticalForceTest struct {
+	on   time.Duration
+	off  time.Duration
+	reps int
+}
+
+// CriticalForceTest is an all-out intermittent test of reps
+// contractions of on, with rests of off, timed by the clock as in
+// Repeaters. Each contraction is recorded separately, with a
+// descriptor which ParseCriticalForceRep understands.
+func CriticalForceTest(on, off time.Duration, reps int) isometric.Workout {
+	return criticalForceTest{
+		on:   on,
+		off:  off,
+		reps: reps,
+	}
+}
+
+func (t criticalForceTest) String() string {
+	return fmt.Sprintf("%s%v-%v-x%d", criticalForcePrefix, t.on, t.off, t.reps)
+}
+
+// CriticalForceRep identifies a contraction of a critical force test
+type CriticalForceRep struct {
+	// Test is the descriptor of the whole test
+	Test string
+	// Rep counts from 1
+	Rep  int
+	Reps int
+	On   time.Duration
+}
+
+func (r CriticalForceRep) String() string {
+	return fmt.Sprintf("%s-rep-%d-of-%d", r.Test, r.Rep, r.Reps)
+}
+
+// ParseCriticalForceRep parses the descriptor of a contraction of a
+// critical force test, returning false if it is something else
+func ParseCriticalForceRep(descriptor string) (CriticalForceRep, bool) {
+	if !strings.HasPrefix(descriptor, criticalForcePrefix) {
+		return CriticalForceRep{}, false
+	}
+	// on-off-xreps-rep-n-of-reps
+	fields := strings.Split(strings.TrimPrefix(descriptor, criticalForcePrefix), "-")
+	if len(fields) != 7 || fields[3] != "rep" || fields[5] != "of" || fields[2] != "x"+fields[6] {
+		return CriticalForceRep{}, false
+	}
+	on, err := time.ParseDuration(fields[0])
+	if err != nil {
+		return CriticalForceRep{}, false
+	}
+	off, err := time.ParseDuration(fields[1])
+	if err != nil {
+		return CriticalForceRep{}, false
+	}
+	rep, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return CriticalForceRep{}, false
+	}
+	reps, err := strconv.Atoi(fields[6])
+	if err != nil {
+		return CriticalForceRep{}, false
+	}
+	return CriticalForceRep{
+		Test: criticalForceTest{on: on, off: off, reps: reps}.String(),
+		Rep:  rep,
+		Reps: reps,
+		On:   on,
+	}, true
+}
+
+func (t criticalForceTest) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
+	defer errutil.SwallowF(func() error { return model.UpdateState(state.Halt()) })
+
+	if err := tare(ctx, model, loadCell, 2*time.Second, 20); err != nil {
+		return err
+	}
+
+	p, err := clockedPhase(ctx, model, state.Rest, time.Now(), repeatersCountdown, loadCell, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	if p.event != control.None {
+		return skipped(p.event)
+	}
+	for i := 0; i < t.reps; i++ {
+		if p, err = t.contraction(ctx, model, i+1, p.end, loadCell, recorder); err != nil {
+			return err
+		}
+		if p.event != control.None || i == t.reps-1 {
+			return skipped(p.event)
+		}
+		if p, err = clockedPhase(ctx, model, state.Rest, p.end, t.off, loadCell, nil, 0, nil); err != nil {
+			return err
+		}
+		if p.event != control.None {
+			return skipped(p.event)
+		}
+	}
+	return nil
+}
+
+func (t criticalForceTest) contraction(ctx context.Context, model display.Model, rep int, start time.Time, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) (phase, error) {
+	descriptor := CriticalForceRep{Test: t.String(), Rep: rep, Reps: t.reps, On: t.on}.String()
+	updater, err := recorder.Start(ctx, descriptor)
+	if err != nil {
+		return phase{}, err
+	}
+	defer updater.Close()
+
+	// the test is all out, so any force will do
+	forceInput := &input.DynamicForceInput{}
+	work := func(deadline time.Time) display.State {
+		return state.Work(input.ForceRequired(0), forceInput, deadline)
+	}
+	p, err := clockedPhase(ctx, model, work, start, t.on, loadCell, updater, 0, forceInput)
+	if err != nil {
+		return phase{}, err
+	}
+	result := isometric.Success
+	if p.event != control.None {
+		result = isometric.Skipped
+	}
+	return p, updater.Finish(isometric.WorkoutOutcome{Result: result})
+}
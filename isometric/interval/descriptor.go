@@ -0,0 +1,79 @@
+package interval
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+// Kinds of interval, as told by ParseDescriptor
+const (
+	KindStatic        = "static"
+	KindMaxTest       = "max-test"
+	KindRepeaters     = "repeaters"
+	KindCriticalForce = "critical-force"
+	KindExplosivePull = "explosive-pull"
+	KindRest          = "rest"
+	KindSetup         = "setup"
+)
+
+var ErrUnknownDescriptor = errors.New("unknown interval descriptor")
+
+// Descriptor is what the descriptor of an interval tells about it
+type Descriptor struct {
+	Kind string
+	// Duration is how long each hold lasts, or how long a rest or
+	// setup lasts, and zero if the descriptor doesn't say
+	Duration time.Duration
+	// Threshold is the force to be held, and zero if the interval
+	// has none
+	Threshold physic.Force
+}
+
+// ParseDescriptor parses the descriptor of any interval in this
+// package, returning ErrUnknownDescriptor if it isn't one
+func ParseDescriptor(descriptor string) (Descriptor, error) {
+	if d, ok := ParseMaxTest(descriptor); ok {
+		return Descriptor{Kind: KindMaxTest, Duration: d}, nil
+	}
+	if r, ok := ParseCriticalForceRep(descriptor); ok {
+		return Descriptor{Kind: KindCriticalForce, Duration: r.On}, nil
+	}
+	if _, _, ok := ParseExplosivePull(descriptor); ok {
+		return Descriptor{Kind: KindExplosivePull}, nil
+	}
+	fields := strings.Split(descriptor, "-")
+	switch {
+	case fields[0] == KindStatic && len(fields) == 3:
+		// static-9s-700N
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return Descriptor{}, ErrUnknownDescriptor
+		}
+		var f physic.Force
+		if err := f.Set(fields[2]); err != nil {
+			return Descriptor{}, ErrUnknownDescriptor
+		}
+		return Descriptor{Kind: KindStatic, Duration: d, Threshold: f}, nil
+	case fields[0] == KindRepeaters && len(fields) == 5:
+		// repeaters-7s-3s-x6-700N
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return Descriptor{}, ErrUnknownDescriptor
+		}
+		var f physic.Force
+		if err := f.Set(fields[4]); err != nil {
+			return Descriptor{}, ErrUnknownDescriptor
+		}
+		return Descriptor{Kind: KindRepeaters, Duration: d, Threshold: f}, nil
+	case (fields[0] == KindRest || fields[0] == KindSetup) && len(fields) == 2:
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return Descriptor{}, ErrUnknownDescriptor
+		}
+		return Descriptor{Kind: fields[0], Duration: d}, nil
+	}
+	return Descriptor{}, ErrUnknownDescriptor
+}
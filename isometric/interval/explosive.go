@@ -0,0 +1,105 @@
+package interval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/input"
+	"github.com/chewr/tension-scale/display/state"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+// pullReady is how long the board is recorded before each pull is
+// cued, long enough to give a quiet baseline to find its onset from
+const pullReady = 2 * time.Second
+
+const explosivePullFormat = "explosive-pull-%d-of-%d"
+
+type explosivePulls struct {
+	reps int
+	pull time.Duration
+	rest time.Duration
+}
+
+// ExplosivePulls is a test of reps short maximal pulls of length pull,
+// separated by rest. Each pull is recorded separately, starting a
+// little before it is cued so that its onset can be found, with a
+// descriptor which ParseExplosivePull understands.
+func ExplosivePulls(reps int, pull, rest time.Duration) isometric.Workout {
+	return explosivePulls{
+		reps: reps,
+		pull: pull,
+		rest: rest,
+	}
+}
+
+func (e explosivePulls) String() string {
+	return fmt.Sprintf("explosive-pulls-%v-x%d", e.pull, e.reps)
+}
+
+// ParseExplosivePull returns the number of the pull, from 1, and the
+// number of pulls in the test, or false if the descriptor is not a
+// pull of ExplosivePulls
+func ParseExplosivePull(descriptor string) (rep, reps int, ok bool) {
+	if _, err := fmt.Sscanf(descriptor, explosivePullFormat, &rep, &reps); err != nil {
+		return 0, 0, false
+	}
+	return rep, reps, fmt.Sprintf(explosivePullFormat, rep, reps) == descriptor
+}
+
+func (e explosivePulls) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
+	defer errutil.SwallowF(func() error { return model.UpdateState(state.Halt()) })
+
+	if err := tare(ctx, model, loadCell, 2*time.Second, 20); err != nil {
+		return err
+	}
+	for i := 1; i <= e.reps; i++ {
+		event, err := e.explosivePull(ctx, model, i, loadCell, recorder)
+		if err != nil {
+			return err
+		}
+		if event != control.None || i == e.reps {
+			return skipped(event)
+		}
+		p, err := clockedPhase(ctx, model, state.Rest, time.Now(), e.rest, loadCell, nil, 0, nil)
+		if err != nil {
+			return err
+		}
+		if p.event != control.None {
+			return skipped(p.event)
+		}
+	}
+	return nil
+}
+
+func (e explosivePulls) explosivePull(ctx context.Context, model display.Model, rep int, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) (control.Event, error) {
+	updater, err := recorder.Start(ctx, fmt.Sprintf(explosivePullFormat, rep, e.reps))
+	if err != nil {
+		return control.None, err
+	}
+	defer updater.Close()
+
+	p, err := clockedPhase(ctx, model, state.Rest, time.Now(), pullReady, loadCell, updater, 0, nil)
+	if err != nil {
+		return control.None, err
+	}
+	if p.event != control.None {
+		return p.event, updater.Finish(isometric.WorkoutOutcome{Result: isometric.Skipped})
+	}
+	forceInput := &input.DynamicForceInput{}
+	pull := func(deadline time.Time) display.State {
+		return state.Work(input.ForceRequired(0), forceInput, deadline)
+	}
+	if p, err = clockedPhase(ctx, model, pull, p.end, e.pull, loadCell, updater, 0, forceInput); err != nil {
+		return control.None, err
+	}
+	if p.event != control.None {
+		return p.event, updater.Finish(isometric.WorkoutOutcome{Result: isometric.Skipped})
+	}
+	return control.None, updater.Finish(isometric.WorkoutOutcome{Result: isometric.Success})
+}
@@ -3,6 +3,7 @@ package interval
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/chewr/tension-scale/display"
@@ -11,6 +12,7 @@ import (
 	"github.com/chewr/tension-scale/errutil"
 	"github.com/chewr/tension-scale/hx711"
 	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
 	"github.com/chewr/tension-scale/loadcell"
 	"periph.io/x/periph/conn/physic"
 )
@@ -21,13 +23,29 @@ func MaxTest(hold time.Duration) isometric.Workout {
 
 type maxTest time.Duration
 
+const maxTestPrefix = "max-test-"
+
 func (t maxTest) String() string {
-	return fmt.Sprintf("max-test-%v", time.Duration(t))
+	return fmt.Sprintf("%s%v", maxTestPrefix, time.Duration(t))
 }
+
+// ParseMaxTest returns the hold duration of the max test with the
+// given descriptor, or false if it is not a max test
+func ParseMaxTest(descriptor string) (time.Duration, bool) {
+	if !strings.HasPrefix(descriptor, maxTestPrefix) {
+		return 0, false
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(descriptor, maxTestPrefix))
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
 func (t maxTest) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
 	defer errutil.SwallowF(func() error { return model.UpdateState(state.Halt()) })
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(t)*3)
-	defer cancel()
+	controls := control.FromContext(ctx)
+	timer := controls.Timer(time.Duration(t) * 3)
 
 	updater, err := recorder.Start(ctx, t.String())
 	if err != nil {
@@ -36,7 +54,8 @@ func (t maxTest) Run(ctx context.Context, model display.Model, loadCell loadcell
 	defer updater.Close()
 
 	risingEdgeInput := &input.DynamicEdgeInput{}
-	if err := model.UpdateState(state.WaitForInput(input.RisingEdge(200*physic.Newton), risingEdgeInput)); err != nil {
+	waitForInput := state.WaitForInput(input.RisingEdge(200*physic.Newton), risingEdgeInput)
+	if err := model.UpdateState(waitForInput); err != nil {
 		return err
 	}
 
@@ -45,9 +64,34 @@ func (t maxTest) Run(ctx context.Context, model display.Model, loadCell loadcell
 	}
 	trueMax := physic.Force(0)
 	for {
+		if e := controls.TakeEvent(); e != control.None {
+			return finishEarly(updater, isometric.WorkoutOutcome{}, e)
+		}
+		if controls.Paused() {
+			if err := model.UpdateState(state.Paused(timer.Remaining())); err != nil {
+				return err
+			}
+			if err := controls.WaitWhilePaused(ctx); err != nil {
+				return err
+			}
+			// the max must be held again for the whole window
+			sw = &slidingWindow{dur: time.Duration(t)}
+			if err := model.UpdateState(waitForInput); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// Read force
-		r, err := loadcell.TryReadIgnoreErrors(ctx, loadCell, hx711.ErrBadRead)
-		if err != nil {
+		readCtx, cancel := context.WithDeadline(ctx, timer.Deadline())
+		r, err := loadcell.TryReadIgnoreErrors(readCtx, loadCell, hx711.ErrBadRead)
+		cancel()
+		switch {
+		case err == nil:
+		case readCtx.Err() != nil && ctx.Err() == nil && timer.Remaining() > 0:
+			// interrupted by a pause
+			continue
+		default:
 			return err
 		}
 
@@ -58,13 +102,15 @@ func (t maxTest) Run(ctx context.Context, model display.Model, loadCell loadcell
 		if err := updater.Write(r); err != nil {
 			return err
 		}
+		// the max is measured, not smoothed; only the display
+		// follows the filtered force
 		if r.Force > trueMax {
 			trueMax = r.Force
 		}
 		sw.update(r)
 		if sw.ready() {
 			if trueMax > sw.maxForce() {
-				return updater.Finish(isometric.Success)
+				return updater.Finish(isometric.WorkoutOutcome{Result: isometric.Success})
 			}
 		}
 	}
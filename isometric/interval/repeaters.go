@@ -0,0 +1,213 @@
+package interval
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/input"
+	"github.com/chewr/tension-scale/display/state"
+	"github.com/chewr/tension-scale/errutil"
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	// repeatersCountdown is how long the user has to get ready
+	// between tare and the first rep
+	repeatersCountdown = 5 * time.Second
+	// repTolerance is how long the force may be below the threshold
+	// during a rep, mostly while loading and unloading, for the rep
+	// to count as held
+	repTolerance = time.Second
+)
+
+type repeaters struct {
+	threshold physic.Force
+	on        time.Duration
+	off       time.Duration
+	reps      int
+}
+
+// Repeaters is a set of reps hung for on and rested for off. The
+// board is tared once before the set, and from then on the switches
+// between work and rest follow the clock rather than the force, so
+// that each rep starts exactly on+off after the previous one, unless
+// the workout is paused. There is no rest after the last rep.
+//
+// The set succeeds if every rep was held, passes if some were, and
+// fails otherwise.
+func Repeaters(threshold physic.Force, on, off time.Duration, reps int) isometric.Workout {
+	return repeaters{
+		threshold: threshold,
+		on:        on,
+		off:       off,
+		reps:      reps,
+	}
+}
+
+func (r repeaters) String() string {
+	return fmt.Sprintf("repeaters-%v-%v-x%d-%s", r.on, r.off, r.reps, r.threshold.String())
+}
+
+func (r repeaters) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
+	defer errutil.SwallowF(func() error { return model.UpdateState(state.Halt()) })
+
+	if err := tare(ctx, model, loadCell, 2*time.Second, 20); err != nil {
+		return err
+	}
+
+	updater, err := recorder.Start(ctx, r.String())
+	if err != nil {
+		return err
+	}
+	defer updater.Close()
+
+	outcome := isometric.WorkoutOutcome{Result: isometric.Failure}
+	p, err := clockedPhase(ctx, model, state.Rest, time.Now(), repeatersCountdown, loadCell, updater, r.threshold, nil)
+	if err != nil {
+		return err
+	}
+	if p.event != control.None {
+		return finishEarly(updater, outcome, p.event)
+	}
+
+	held := 0
+	for i := 0; i < r.reps; i++ {
+		forceInput := &input.DynamicForceInput{}
+		work := func(deadline time.Time) display.State {
+			return state.Work(input.ForceRequired(r.threshold), forceInput, deadline)
+		}
+		if p, err = clockedPhase(ctx, model, work, p.end, r.on, loadCell, updater, r.threshold, forceInput); err != nil {
+			return err
+		}
+		outcome.BelowThreshold += p.below
+		if p.event != control.None {
+			return finishEarly(updater, outcome, p.event)
+		}
+		if p.below <= repTolerance {
+			held++
+		}
+		if i == r.reps-1 {
+			break
+		}
+		if p, err = clockedPhase(ctx, model, state.Rest, p.end, r.off, loadCell, updater, r.threshold, nil); err != nil {
+			return err
+		}
+		if p.event != control.None {
+			return finishEarly(updater, outcome, p.event)
+		}
+	}
+
+	switch {
+	case held == r.reps:
+		outcome.Result = isometric.Success
+	case held > 0:
+		outcome.Result = isometric.Pass
+	}
+	return updater.Finish(outcome)
+}
+
+// phase is how a clocked phase went
+type phase struct {
+	// end is when the phase ended, allowing for pauses, from which
+	// the next phase is timed
+	end time.Time
+	// below is how long the force was below threshold in a work
+	// phase
+	below time.Duration
+	// event is the control event which ended the phase early, if any
+	event control.Event
+}
+
+// clockedPhase shows the state s gives for the phase's deadline and
+// records samples to updater, if there is one, for d from start. The
+// clock stops while the workout is paused, and a skip or repeat ends
+// the phase early. If there is a force input to update, it is a work
+// phase, and clockedPhase reports how long the force was below
+// threshold.
+func clockedPhase(ctx context.Context, model display.Model, s func(deadline time.Time) display.State, start time.Time, d time.Duration, loadCell loadcell.Sensor, updater isometric.WorkoutUpdater, threshold physic.Force, forceInput *input.DynamicForceInput) (phase, error) {
+	controls := control.FromContext(ctx)
+	timer := controls.TimerFrom(start, d)
+	if err := model.UpdateState(s(timer.Deadline())); err != nil {
+		return phase{}, err
+	}
+
+	var p phase
+	last := start
+	for {
+		if p.event = controls.TakeEvent(); p.event != control.None {
+			p.end = time.Now()
+			return p, nil
+		}
+		if controls.Paused() {
+			if err := model.UpdateState(state.Paused(timer.Remaining())); err != nil {
+				return phase{}, err
+			}
+			if err := controls.WaitWhilePaused(ctx); err != nil {
+				return phase{}, err
+			}
+			// the force isn't held to the threshold while paused
+			last = time.Now()
+			if err := model.UpdateState(s(timer.Deadline())); err != nil {
+				return phase{}, err
+			}
+			continue
+		}
+
+		phaseCtx, cancel := context.WithDeadline(ctx, timer.Deadline())
+		fs, err := loadCell.Read(phaseCtx)
+		cancel()
+		switch {
+		case err == nil:
+		case err == hx711.ErrBadRead:
+			continue
+		case phaseCtx.Err() != nil && ctx.Err() == nil:
+			if timer.Remaining() > 0 {
+				// interrupted by a pause
+				continue
+			}
+			p.end = timer.End()
+			return p, nil
+		default:
+			return phase{}, err
+		}
+		if updater != nil {
+			if err := updater.Write(fs); err != nil {
+				return phase{}, err
+			}
+		}
+		if forceInput == nil {
+			continue
+		}
+		forceInput.UpdateForceInput(fs.Filtered())
+		if fs.Filtered() < threshold && fs.Time.After(last) {
+			p.below += fs.Time.Sub(last)
+		}
+		last = fs.Time
+	}
+}
+
+// finishEarly finishes an interval which was skipped or is to be
+// repeated because of e, returning control.ErrRepeat if it is to be
+// run again
+func finishEarly(updater isometric.WorkoutUpdater, outcome isometric.WorkoutOutcome, e control.Event) error {
+	outcome.Result = isometric.Skipped
+	if err := updater.Finish(outcome); err != nil {
+		return err
+	}
+	return skipped(e)
+}
+
+// skipped returns the error with which an interval ended early by e
+// returns
+func skipped(e control.Event) error {
+	if e == control.Repeat {
+		return control.ErrRepeat
+	}
+	return nil
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/chewr/tension-scale/display"
 	"github.com/chewr/tension-scale/display/state"
+	"github.com/chewr/tension-scale/hx711"
 	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
 	"github.com/chewr/tension-scale/loadcell"
 )
 
@@ -17,16 +19,49 @@ func (r restInterval) String() string {
 	return fmt.Sprintf("rest-%v", time.Duration(r))
 }
 
-func (r restInterval) Run(ctx context.Context, model display.Model, _ loadcell.Sensor, _ isometric.WorkoutRecorder) error {
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(r))
-	defer cancel()
-
-	if err := model.UpdateState(state.Rest(time.Now().Add(time.Duration(r)))); err != nil {
+func (r restInterval) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
+	start := time.Now()
+	timer := control.FromContext(ctx).Timer(time.Duration(r))
+	if err := model.UpdateState(state.Rest(timer.Deadline())); err != nil {
 		return err
 	}
 
-	<-ctx.Done()
-	return nil
+	// keep reading while resting, so that zero tracking sees the
+	// unloaded board
+	readCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for loadCell != nil && readCtx.Err() == nil {
+			if _, err := loadCell.Read(readCtx); err != nil && err != hx711.ErrBadRead {
+				break
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	e, err := timer.Wait(ctx,
+		func(remaining time.Duration) error { return model.UpdateState(state.Paused(remaining)) },
+		func(deadline time.Time) error { return model.UpdateState(state.Rest(deadline)) },
+	)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		// cancelled as the rest ended, so it didn't finish
+		return err
+	}
+	result := isometric.Success
+	switch e {
+	case control.Repeat:
+		return control.ErrRepeat
+	case control.Skip:
+		result = isometric.Skipped
+	}
+	return isometric.RecordEvent(ctx, recorder, r.String(), start, time.Now(), result)
 }
 
 func RestInterval(r time.Duration) isometric.Workout {
@@ -25,21 +25,22 @@ func (s setupInterval) String() string {
 	return fmt.Sprintf("setup-%v", time.Duration(s))
 }
 
-func (s setupInterval) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, _ isometric.WorkoutRecorder) error {
+func (s setupInterval) Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder isometric.WorkoutRecorder) error {
+	start := time.Now()
+	if err := s.run(ctx, model, loadCell); err != nil {
+		return err
+	}
+	return isometric.RecordEvent(ctx, recorder, s.String(), start, time.Now(), isometric.Success)
+}
+
+func (s setupInterval) run(ctx context.Context, model display.Model, loadCell loadcell.Sensor) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(s))
 	defer cancel()
 	defer errutil.SwallowF(func() error { return model.UpdateState(state.Halt()) })
 
-	tareDur := 5 * time.Second
-	done := time.After(tareDur)
-	if err := model.UpdateState(state.Tare(time.Now().Add(tareDur))); err != nil {
-		return err
-	}
-	time.Sleep(time.Second)
-	if err := loadCell.Tare(ctx, 40); err != nil {
+	if err := tare(ctx, model, loadCell, 5*time.Second, 40); err != nil {
 		return err
 	}
-	<-done
 
 	risingEdgeInput := &input.DynamicEdgeInput{}
 	if err := model.UpdateState(state.WaitForInput(input.RisingEdge(100*physic.Newton), risingEdgeInput)); err != nil {
@@ -51,7 +52,7 @@ func (s setupInterval) Run(ctx context.Context, model display.Model, loadCell lo
 			return err
 		}
 		risingEdgeInput.Update(fs)
-		if fs.Force >= 20*physic.PoundForce {
+		if fs.Filtered() >= 20*physic.PoundForce {
 			break
 		}
 	}
@@ -0,0 +1,44 @@
+package interval
+
+import (
+	"context"
+	"time"
+
+	"github.com/chewr/tension-scale/display"
+	"github.com/chewr/tension-scale/display/state"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+// unloadDelay is how long the user is given to get off the board
+// before tare is retried
+const unloadDelay = 2 * time.Second
+
+// tare zeroes the load cell from the given number of samples,
+// showing the tare state for at least tareDur. While tare is rejected
+// because the board is loaded, the user is asked to unload it.
+func tare(ctx context.Context, model display.Model, loadCell loadcell.Sensor, tareDur time.Duration, samples int) error {
+	for {
+		if err := model.UpdateState(state.Tare(time.Now().Add(tareDur))); err != nil {
+			return err
+		}
+		done := time.After(tareDur)
+		time.Sleep(time.Second)
+		_, err := loadCell.Tare(ctx, samples)
+		if _, loaded := err.(*loadcell.BoardLoadedError); !loaded {
+			if err != nil {
+				return err
+			}
+			<-done
+			return nil
+		}
+
+		if err := model.UpdateState(state.Unload()); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(unloadDelay):
+		}
+	}
+}
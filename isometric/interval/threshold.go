@@ -0,0 +1,137 @@
+package interval
+
+import (
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// DipPolicy decides what happens when the force drops below the
+// threshold partway through a rep
+type DipPolicy struct {
+	fail  bool
+	grace time.Duration
+}
+
+var (
+	// PauseOnDip stops the clock while the force is below the
+	// threshold, so the full time under tension must still be held
+	PauseOnDip = DipPolicy{}
+	// FailOnDip fails the rep as soon as the force drops below the
+	// threshold
+	FailOnDip = DipPolicy{fail: true}
+)
+
+// GraceOnDip keeps the clock running through dips shorter than d, and
+// fails the rep on longer ones
+func GraceOnDip(d time.Duration) DipPolicy {
+	return DipPolicy{fail: true, grace: d}
+}
+
+// thresholdTracker times a rep from debounced threshold crossings.
+// The force must rise above the threshold to count as above it, and
+// fall below the threshold less the hysteresis to count as below it,
+// and in both cases must stay there for the dwell time.
+type thresholdTracker struct {
+	// immutable
+	threshold  physic.Force
+	hysteresis physic.Force
+	dwell      time.Duration
+	policy     DipPolicy
+
+	// mutable
+	above bool
+	// pending is when the force started crossing to the other side
+	pending time.Time
+	// dipStart is when the current dip started, once confirmed
+	dipStart       time.Time
+	started        bool
+	last           time.Time
+	underTension   time.Duration
+	belowThreshold time.Duration
+}
+
+// resume stops the time since the last sample, during which the
+// workout was paused, from counting for or against the rep
+func (t *thresholdTracker) resume(at time.Time) {
+	t.pending = time.Time{}
+	if !t.started {
+		return
+	}
+	if !t.dipStart.IsZero() {
+		t.dipStart = t.dipStart.Add(at.Sub(t.last))
+	}
+	t.last = at
+}
+
+// reattribute moves the time since a confirmed crossing started at
+// from the side the force crossed from to the side it is now on. The
+// clock runs through a dip within the grace period, so only the time
+// below threshold changes then.
+func (t *thresholdTracker) reattribute(from time.Time) {
+	d := t.last.Sub(from)
+	if d <= 0 {
+		return
+	}
+	graced := t.policy.fail && t.policy.grace > 0
+	if t.above {
+		t.belowThreshold -= d
+		if !graced {
+			t.underTension += d
+		}
+		return
+	}
+	t.belowThreshold += d
+	if !graced {
+		t.underTension -= d
+	}
+}
+
+// update adds a sample, returning false if the rep has failed
+func (t *thresholdTracker) update(fs loadcell.ForceSample) bool {
+	f := fs.Filtered()
+	crossing := (!t.above && f > t.threshold) ||
+		(t.above && f < t.threshold-t.hysteresis)
+	switch {
+	case !crossing:
+		t.pending = time.Time{}
+	case t.pending.IsZero():
+		t.pending = fs.Time
+	}
+	if crossing && fs.Time.Sub(t.pending) >= t.dwell {
+		t.above = !t.above
+		if t.above && !t.started {
+			// the clock starts from when the force first crossed,
+			// not from when the crossing was confirmed
+			t.started = true
+			t.last = t.pending
+		} else if t.started {
+			t.reattribute(t.pending)
+		}
+		if !t.above {
+			t.dipStart = t.pending
+		}
+		t.pending = time.Time{}
+	}
+
+	if !t.started {
+		return true
+	}
+	elapsed := fs.Time.Sub(t.last)
+	t.last = fs.Time
+	if t.above {
+		t.underTension += elapsed
+		return true
+	}
+	t.belowThreshold += elapsed
+	if !t.policy.fail {
+		return true
+	}
+	if t.policy.grace == 0 || fs.Time.Sub(t.dipStart) > t.policy.grace {
+		return false
+	}
+	// the clock runs on through the grace period
+	t.underTension += elapsed
+	return true
+}
@@ -0,0 +1,50 @@
+package interval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestThresholdTrackerDwell(t *testing.T) {
+	const step = 10 * time.Millisecond
+	start := time.Now()
+	// held for 1s, dipping for 300ms, then held again for 500ms
+	force := func(i int) physic.Force {
+		if i >= 100 && i < 130 {
+			return 0
+		}
+		return 100 * physic.Newton
+	}
+	for _, tc := range []struct {
+		name         string
+		samples      int
+		policy       DipPolicy
+		underTension time.Duration
+		below        time.Duration
+	}{
+		{"dipping", 130, PauseOnDip, 1000 * time.Millisecond, 290 * time.Millisecond},
+		{"recovered", 180, PauseOnDip, 1490 * time.Millisecond, 300 * time.Millisecond},
+		{"dipping with grace", 130, GraceOnDip(time.Second), 1290 * time.Millisecond, 290 * time.Millisecond},
+		{"recovered with grace", 180, GraceOnDip(time.Second), 1790 * time.Millisecond, 300 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := &thresholdTracker{
+				threshold: 50 * physic.Newton,
+				dwell:     100 * time.Millisecond,
+				policy:    tc.policy,
+			}
+			for i := 0; i < tc.samples; i++ {
+				if !tracker.update(loadcell.ForceSample{Force: force(i), Time: start.Add(time.Duration(i) * step)}) {
+					t.Fatalf("rep failed at sample %d", i)
+				}
+			}
+			if tracker.underTension != tc.underTension || tracker.belowThreshold != tc.below {
+				t.Errorf("got %v under tension and %v below threshold, want %v and %v",
+					tracker.underTension, tracker.belowThreshold, tc.underTension, tc.below)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/chewr/tension-scale/errutil"
 	"github.com/chewr/tension-scale/hx711"
 	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/control"
 	"github.com/chewr/tension-scale/loadcell"
 	"periph.io/x/periph/conn/physic"
 )
@@ -18,6 +19,44 @@ import (
 type workInterval struct {
 	threshold        physic.Force
 	timeUnderTension time.Duration
+	hysteresis       physic.Force
+	dwell            time.Duration
+	dipPolicy        DipPolicy
+}
+
+// WorkOption configures a work interval
+type WorkOption interface {
+	apply(w *workInterval)
+}
+
+type workOptFn func(w *workInterval)
+
+func (fn workOptFn) apply(w *workInterval) {
+	fn(w)
+}
+
+// WithHysteresis sets how far below the threshold the force must fall
+// before it counts as having dropped below it
+func WithHysteresis(band physic.Force) WorkOption {
+	return workOptFn(func(w *workInterval) {
+		w.hysteresis = band
+	})
+}
+
+// WithDwell sets how long the force must stay across the threshold
+// before a crossing counts, so that brief spikes and dips are ignored
+func WithDwell(d time.Duration) WorkOption {
+	return workOptFn(func(w *workInterval) {
+		w.dwell = d
+	})
+}
+
+// WithDipPolicy sets what happens when the force drops below the
+// threshold partway through a rep. The default is PauseOnDip.
+func WithDipPolicy(p DipPolicy) WorkOption {
+	return workOptFn(func(w *workInterval) {
+		w.dipPolicy = p
+	})
 }
 
 func (w workInterval) String() string {
@@ -31,23 +70,15 @@ func (w workInterval) Run(ctx context.Context, model display.Model, loadCell loa
 	defer errutil.SwallowF(func() error { return model.UpdateState(state.Halt()) })
 
 	// Tare + setup
-	tareDur := 2 * time.Second
-	if err := model.UpdateState(state.Tare(time.Now().Add(tareDur))); err != nil {
+	if err := tare(ctx, model, loadCell, 2*time.Second, 20); err != nil {
 		return err
 	}
-	done := time.After(tareDur)
-	time.Sleep(time.Second)
-	if err := loadCell.Tare(ctx, 20); err != nil {
-		return err
-	}
-	<-done
 
-	deadline := time.Now().Add(15*time.Second + 2*w.timeUnderTension)
-	ctx, cancel := context.WithDeadline(ctx, deadline)
-	defer cancel()
+	controls := control.FromContext(ctx)
+	timer := controls.Timer(15*time.Second + 2*w.timeUnderTension)
 
 	forceInput := &input.DynamicForceInput{}
-	if err := model.UpdateState(state.WaitForInputWithTimeout(input.ForceRequired(w.threshold), forceInput, deadline)); err != nil {
+	if err := model.UpdateState(state.WaitForInputWithTimeout(input.ForceRequired(w.threshold), forceInput, timer.Deadline())); err != nil {
 		return err
 	}
 
@@ -63,46 +94,87 @@ func (w workInterval) Run(ctx context.Context, model display.Model, loadCell loa
 	}
 	defer updater.Close()
 
-	underTension := false
-	var startTime time.Time
+	tracker := &thresholdTracker{
+		threshold:  w.threshold,
+		hysteresis: w.hysteresis,
+		dwell:      w.dwell,
+		policy:     w.dipPolicy,
+	}
+	finish := func(result isometric.WorkoutResult) error {
+		return updater.Finish(isometric.WorkoutOutcome{
+			Result:         result,
+			BelowThreshold: tracker.belowThreshold,
+		})
+	}
 	for {
+		switch controls.TakeEvent() {
+		case control.Skip:
+			return finish(isometric.Skipped)
+		case control.Repeat:
+			if err := finish(isometric.Skipped); err != nil {
+				return err
+			}
+			return control.ErrRepeat
+		}
+		if controls.Paused() {
+			if err := model.UpdateState(state.Paused(timer.Remaining())); err != nil {
+				return err
+			}
+			if err := controls.WaitWhilePaused(ctx); err != nil {
+				return err
+			}
+			// the clock stays stopped for the length of the pause
+			tracker.resume(time.Now())
+			if err := model.UpdateState(state.WaitForInputWithTimeout(input.ForceRequired(w.threshold), forceInput, timer.Deadline())); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// Read force
-		r, err := loadCell.Read(ctx)
-		switch err {
-		case nil: // continue processing
-		case hx711.ErrBadRead:
+		readCtx, cancel := context.WithDeadline(ctx, timer.Deadline())
+		r, err := loadCell.Read(readCtx)
+		cancel()
+		switch {
+		case err == nil: // continue processing
+		case err == hx711.ErrBadRead:
 			continue // drop a bad reading and continue
-		case context.DeadlineExceeded:
-			return updater.Finish(isometric.Failure)
+		case readCtx.Err() != nil && ctx.Err() == nil:
+			if timer.Remaining() > 0 {
+				// interrupted by a pause
+				continue
+			}
+			return finish(isometric.Failure)
 		default:
 			return err
 		}
 
 		// Update model state
-		forceInput.UpdateForceInput(r.Force)
+		forceInput.UpdateForceInput(r.Filtered())
 
 		// record data
 		if err := updater.Write(r); err != nil {
 			return err
 		}
 
-		// Start clock once threshold is passed
-		if !underTension && r.Force > w.threshold {
-			underTension = true
-			startTime = r.Time
-		}
-
 		// Loop branch control
-		// this is done before updating model state to avoid negative durations
-		if underTension && time.Now().Sub(startTime) > w.timeUnderTension {
-			return updater.Finish(isometric.Success)
+		if !tracker.update(r) {
+			return finish(isometric.Failure)
+		}
+		if tracker.underTension > w.timeUnderTension {
+			return finish(isometric.Success)
 		}
 	}
 }
 
-func WorkInterval(t physic.Force, tut time.Duration) isometric.Workout {
-	return &workInterval{
+func WorkInterval(t physic.Force, tut time.Duration, opts ...WorkOption) isometric.Workout {
+	w := &workInterval{
 		threshold:        t,
 		timeUnderTension: tut,
+		dipPolicy:        PauseOnDip,
+	}
+	for _, opt := range opts {
+		opt.apply(w)
 	}
+	return w
 }
@@ -3,6 +3,7 @@ package isometric
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/chewr/tension-scale/loadcell"
 )
@@ -21,3 +22,19 @@ type WorkoutUpdater interface {
 	Finish(outcome WorkoutOutcome) error
 	Close()
 }
+
+// EventRecorder is implemented by recorders which keep a timeline of
+// intervals with no samples to record, such as rests. The result
+// tells an interval which ran its course from one which was Skipped.
+type EventRecorder interface {
+	Event(ctx context.Context, descriptor string, start, end time.Time, result WorkoutResult) error
+}
+
+// RecordEvent records an interval with no samples on r, if r keeps a
+// timeline
+func RecordEvent(ctx context.Context, r WorkoutRecorder, descriptor string, start, end time.Time, result WorkoutResult) error {
+	if er, ok := r.(EventRecorder); ok {
+		return er.Event(ctx, descriptor, start, end, result)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Package session records a workout session as a whole: what was run,
+// by whom and with which parameters, and the timeline of its
+// intervals, in order and with their outcomes
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+// Kinds of interval
+const (
+	// Recorded intervals have samples, which are written by other
+	// recorders
+	Recorded = "recorded"
+	// Event intervals, such as rests and setup, have none
+	Event = "event"
+)
+
+// Info describes a session
+type Info struct {
+	Protocol string `json:"protocol"`
+	// Parameters are those given for the protocol, such as week and
+	// threshold
+	Parameters map[string]string `json:"parameters,omitempty"`
+	User       string            `json:"user,omitempty"`
+}
+
+// Record is a session and its timeline
+type Record struct {
+	Info
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	// End is zero until the session has ended
+	End       time.Time  `json:"end"`
+	Intervals []Interval `json:"intervals"`
+}
+
+// Interval is an interval of a session
+type Interval struct {
+	// Index is the position of the interval in the session, from 0
+	Index      int       `json:"index"`
+	Descriptor string    `json:"descriptor"`
+	Kind       string    `json:"kind"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	// Outcome is empty for intervals still running, and for events
+	// and imported intervals from before outcomes were recorded
+	Outcome        isometric.WorkoutResult `json:"outcome,omitempty"`
+	BelowThreshold time.Duration           `json:"below-threshold,omitempty"`
+	Samples        int                     `json:"samples,omitempty"`
+}
+
+// Store saves sessions. Save is called with the whole record each
+// time it changes.
+type Store interface {
+	Save(r Record) error
+}
+
+// Session is a recorder which keeps the timeline of a session,
+// saving it to a store as each interval starts and ends
+type Session struct {
+	store Store
+
+	mu     sync.Mutex
+	record Record
+}
+
+// ID returns an identifier for a session of protocol starting at t,
+// which sorts by time
+func ID(t time.Time, protocol string) string {
+	return fmt.Sprintf("%s-%s", t.Format("20060102T150405"), protocol)
+}
+
+// Start starts the session id, saving it to store
+func Start(id string, info Info, store Store) (*Session, error) {
+	s := &Session{
+		store: store,
+		record: Record{
+			Info:  info,
+			ID:    id,
+			Start: time.Now(),
+		},
+	}
+	if err := s.store.Save(s.snapshot()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ID returns the identifier of the session
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record.ID
+}
+
+// snapshot copies the record. It must be called with s.mu held.
+func (s *Session) snapshot() Record {
+	r := s.record
+	r.Intervals = append([]Interval(nil), s.record.Intervals...)
+	return r
+}
+
+// update changes the record with fn and saves it
+func (s *Session) update(fn func(r *Record)) error {
+	s.mu.Lock()
+	fn(&s.record)
+	r := s.snapshot()
+	s.mu.Unlock()
+	return s.store.Save(r)
+}
+
+// End marks the session as ended and saves it. Intervals which
+// haven't finished are marked aborted.
+func (s *Session) End() error {
+	return s.update(func(r *Record) {
+		r.End = time.Now()
+		for i := range r.Intervals {
+			if r.Intervals[i].Kind == Recorded && r.Intervals[i].Outcome == "" {
+				r.Intervals[i].Outcome = isometric.Aborted
+				r.Intervals[i].End = r.End
+			}
+		}
+	})
+}
+
+// Start implements isometric.WorkoutRecorder
+func (s *Session) Start(_ context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
+	var index int
+	err := s.update(func(r *Record) {
+		index = len(r.Intervals)
+		r.Intervals = append(r.Intervals, Interval{
+			Index:      index,
+			Descriptor: descriptor,
+			Kind:       Recorded,
+			Start:      time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updater{session: s, index: index}, nil
+}
+
+// Event implements isometric.EventRecorder
+func (s *Session) Event(_ context.Context, descriptor string, start, end time.Time, result isometric.WorkoutResult) error {
+	return s.update(func(r *Record) {
+		r.Intervals = append(r.Intervals, Interval{
+			Index:      len(r.Intervals),
+			Descriptor: descriptor,
+			Kind:       Event,
+			Start:      start,
+			End:        end,
+			Outcome:    result,
+		})
+	})
+}
+
+type updater struct {
+	session *Session
+	index   int
+
+	mu      sync.Mutex
+	samples int
+	closed  bool
+}
+
+func (u *updater) Write(samples ...loadcell.ForceSample) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.samples += len(samples)
+	return nil
+}
+
+func (u *updater) Finish(outcome isometric.WorkoutOutcome) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.closed = true
+	return u.end(outcome)
+}
+
+func (u *updater) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return
+	}
+	u.closed = true
+	// there is nowhere to report an error, and the session is saved
+	// again as it goes on
+	_ = u.end(isometric.WorkoutOutcome{Result: isometric.Aborted})
+}
+
+func (u *updater) end(outcome isometric.WorkoutOutcome) error {
+	return u.session.update(func(r *Record) {
+		i := &r.Intervals[u.index]
+		i.End = time.Now()
+		i.Outcome = outcome.Result
+		i.BelowThreshold = outcome.BelowThreshold
+		i.Samples = u.samples
+	})
+}
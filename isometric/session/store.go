@@ -0,0 +1,53 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type fileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// FileStore saves a session as JSON at path. Each save replaces the
+// file atomically, so it always holds a complete record.
+func FileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) Save(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Load reads a session saved by FileStore
+func Load(path string) (Record, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, err
+	}
+	var r Record
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
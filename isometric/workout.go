@@ -3,19 +3,40 @@ package isometric
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/chewr/tension-scale/display"
 	"github.com/chewr/tension-scale/loadcell"
 )
 
-type WorkoutOutcome string
+type WorkoutResult string
 
 const (
-	Success WorkoutOutcome = "success"
-	Pass    WorkoutOutcome = "pass"
-	Failure WorkoutOutcome = "failure"
+	Success WorkoutResult = "success"
+	Pass    WorkoutResult = "pass"
+	Failure WorkoutResult = "failure"
+	// Skipped is the result of an interval which was skipped, or
+	// abandoned to be repeated, before it finished
+	Skipped WorkoutResult = "skipped"
+	// Aborted is the result of an interval which was stopped, by an
+	// error or by the workout being cancelled, before it finished
+	Aborted WorkoutResult = "aborted"
 )
 
+type WorkoutOutcome struct {
+	Result WorkoutResult
+	// BelowThreshold is how long the force was below the threshold
+	// after the rep started
+	BelowThreshold time.Duration
+}
+
+func (o WorkoutOutcome) String() string {
+	if o.BelowThreshold > 0 {
+		return fmt.Sprintf("%s (%v below threshold)", o.Result, o.BelowThreshold.Round(time.Millisecond))
+	}
+	return string(o.Result)
+}
+
 type Workout interface {
 	fmt.Stringer
 	Run(ctx context.Context, model display.Model, loadCell loadcell.Sensor, recorder WorkoutRecorder) error
@@ -50,6 +50,10 @@ func colorFromState(state display.State) (color, error) {
 		baseColor = yellow
 	case display.Wait:
 		baseColor = yellow
+	case display.Unload:
+		baseColor = red | yellow
+	case display.Paused:
+		baseColor = yellow
 	default:
 		return baseColor, errors.New("State not recognized")
 	}
@@ -3,6 +3,7 @@ package loadcell
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
 	"periph.io/x/periph/conn/physic"
@@ -15,11 +16,52 @@ var (
 type ForceSample struct {
 	physic.Force
 	time.Time
+	// Cells holds the force measured by each load cell when the
+	// sample is combined from several, and is nil otherwise
+	Cells []physic.Force
+
+	filtered   physic.Force
+	isFiltered bool
+}
+
+// Filtered returns the force smoothed for display and control, or the
+// measured force if the sample has not been filtered. Force is always
+// the measured force, which is what recorders keep.
+func (s ForceSample) Filtered() physic.Force {
+	if s.isFiltered {
+		return s.filtered
+	}
+	return s.Force
+}
+
+// WithFiltered returns a copy of the sample with the filtered force f
+func (s ForceSample) WithFiltered(f physic.Force) ForceSample {
+	s.filtered = f
+	s.isFiltered = true
+	return s
+}
+
+// Imbalance returns the difference between the most and least loaded
+// cells as a fraction of the total force, or 0 for a single cell
+func (s ForceSample) Imbalance() float64 {
+	if len(s.Cells) < 2 || s.Force == 0 {
+		return 0
+	}
+	lo, hi := s.Cells[0], s.Cells[0]
+	for _, f := range s.Cells[1:] {
+		if f < lo {
+			lo = f
+		}
+		if f > hi {
+			hi = f
+		}
+	}
+	return math.Abs(float64(hi-lo) / float64(s.Force))
 }
 
 // Sensor defines the API of a load cell sensor
 type Sensor interface {
-	Tare(ctx context.Context, samples int) error
+	Tare(ctx context.Context, samples int) (TareResult, error)
 	Reset(ctx context.Context) error
 	Halt() error
 	Read(ctx context.Context) (ForceSample, error)
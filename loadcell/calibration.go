@@ -0,0 +1,196 @@
+package loadcell
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+var (
+	ErrNoCalibration        = errors.New("calibration spec is empty")
+	ErrAmbiguousCalibration = errors.New("calibration spec has more than one calibration")
+)
+
+// maxForce bounds forces computed from float calibrations, which
+// would otherwise overflow physic.Force for absurd readings
+const maxForce = float64(math.MaxInt64)
+
+// toForce converts newtons to a force, rounding to the nearest
+// nanonewton and saturating rather than overflowing
+func toForce(newtons float64) physic.Force {
+	nn := math.Round(newtons * float64(physic.Newton))
+	switch {
+	case math.IsNaN(nn):
+		return 0
+	case nn >= maxForce:
+		return physic.Force(math.MaxInt64)
+	case nn <= -maxForce:
+		return physic.Force(math.MinInt64)
+	}
+	return physic.Force(nn)
+}
+
+func newtons(f physic.Force) float64 {
+	return float64(f) / float64(physic.Newton)
+}
+
+// toRaw rounds a reading to the nearest integer within the HX711's
+// 24 bit range
+func toRaw(raw float64) int64 {
+	const minRaw, maxRaw = -(1 << 23), 1<<23 - 1
+	if math.IsNaN(raw) {
+		return 0
+	}
+	return int64(math.Max(minRaw, math.Min(maxRaw, math.Round(raw))))
+}
+
+// LinearCalibration converts readings to force as Slope·(raw−Offset).
+//
+// ToForce is within 1nN of the exact value for forces up to 2⁵²nN,
+// about 4.5MN, which covers the 24 bit range of the HX711 for any
+// slope under 0.5N per unit; beyond that the error is within one
+// part in 2⁵². ToRaw recovers the reading exactly wherever the slope
+// is at least 1nN per unit.
+type LinearCalibration struct {
+	// Offset is the reading with no load
+	Offset float64 `json:"offset" yaml:"offset" mapstructure:"offset"`
+	// Slope is in newtons per unit
+	Slope float64 `json:"slope" yaml:"slope" mapstructure:"slope"`
+}
+
+func (c LinearCalibration) ToForce(raw int64) physic.Force {
+	return toForce(c.Slope * (float64(raw) - c.Offset))
+}
+
+func (c LinearCalibration) ToRaw(f physic.Force) int64 {
+	if c.Slope == 0 {
+		return toRaw(c.Offset)
+	}
+	return toRaw(newtons(f)/c.Slope + c.Offset)
+}
+
+// CalibrationKnot is a reading and the force measured at it
+type CalibrationKnot struct {
+	Raw     float64 `json:"raw" yaml:"raw" mapstructure:"raw"`
+	Newtons float64 `json:"newtons" yaml:"newtons" mapstructure:"newtons"`
+}
+
+// PiecewiseCalibration interpolates linearly between measured knots,
+// and extrapolates from the first and last segments beyond them. The
+// knots must be in order of reading, and force must increase with the
+// reading for ToRaw to be meaningful.
+//
+// The error bounds of LinearCalibration apply to each segment.
+type PiecewiseCalibration struct {
+	Knots []CalibrationKnot `json:"knots" yaml:"knots" mapstructure:"knots"`
+}
+
+// NewPiecewiseCalibration returns a calibration through the given
+// tared points, and through zero force at a zero reading
+func NewPiecewiseCalibration(points []CalibrationPoint) (PiecewiseCalibration, error) {
+	if len(points) == 0 {
+		return PiecewiseCalibration{}, ErrNotEnoughPoints
+	}
+	knots := make([]CalibrationKnot, 0, len(points)+1)
+	knots = append(knots, CalibrationKnot{})
+	for _, p := range points {
+		knots = append(knots, CalibrationKnot{Raw: p.Raw, Newtons: newtons(p.Force)})
+	}
+	sort.Slice(knots, func(i, j int) bool {
+		return knots[i].Raw < knots[j].Raw
+	})
+	for i := 1; i < len(knots); i++ {
+		if knots[i].Raw == knots[i-1].Raw {
+			return PiecewiseCalibration{}, ErrDegenerateFit
+		}
+	}
+	return PiecewiseCalibration{Knots: knots}, nil
+}
+
+func byRaw(k CalibrationKnot) float64     { return k.Raw }
+func byNewtons(k CalibrationKnot) float64 { return k.Newtons }
+
+// segment returns the index of the first knot of the segment which
+// covers x, where key extracts the knot's coordinate
+func (c PiecewiseCalibration) segment(x float64, key func(CalibrationKnot) float64) int {
+	i := sort.Search(len(c.Knots), func(i int) bool {
+		return key(c.Knots[i]) > x
+	})
+	// clamp to the end segments for extrapolation
+	if i < 1 {
+		i = 1
+	}
+	if i > len(c.Knots)-1 {
+		i = len(c.Knots) - 1
+	}
+	return i - 1
+}
+
+func (c PiecewiseCalibration) ToForce(raw int64) physic.Force {
+	if len(c.Knots) < 2 {
+		return 0
+	}
+	x := float64(raw)
+	i := c.segment(x, byRaw)
+	a, b := c.Knots[i], c.Knots[i+1]
+	return toForce(a.Newtons + (x-a.Raw)*(b.Newtons-a.Newtons)/(b.Raw-a.Raw))
+}
+
+func (c PiecewiseCalibration) ToRaw(f physic.Force) int64 {
+	if len(c.Knots) < 2 {
+		return 0
+	}
+	y := newtons(f)
+	i := c.segment(y, byNewtons)
+	a, b := c.Knots[i], c.Knots[i+1]
+	if a.Newtons == b.Newtons {
+		return toRaw(a.Raw)
+	}
+	return toRaw(a.Raw + (y-a.Newtons)*(b.Raw-a.Raw)/(b.Newtons-a.Newtons))
+}
+
+// CalibrationSpec is the serialized form of a calibration, in which
+// exactly one field is set
+type CalibrationSpec struct {
+	Linear     *LinearCalibration    `json:"linear,omitempty" yaml:"linear,omitempty" mapstructure:"linear"`
+	Piecewise  *PiecewiseCalibration `json:"piecewise,omitempty" yaml:"piecewise,omitempty" mapstructure:"piecewise"`
+	Polynomial PolynomialCalibration `json:"polynomial,omitempty" yaml:"polynomial,omitempty" mapstructure:"polynomial"`
+}
+
+// Calibration returns the calibration described by the spec
+func (s CalibrationSpec) Calibration() (InvertibleCalibration, error) {
+	var found []InvertibleCalibration
+	if s.Linear != nil {
+		found = append(found, *s.Linear)
+	}
+	if s.Piecewise != nil {
+		found = append(found, *s.Piecewise)
+	}
+	if len(s.Polynomial) > 0 {
+		found = append(found, s.Polynomial)
+	}
+	switch len(found) {
+	case 0:
+		return nil, ErrNoCalibration
+	case 1:
+		return found[0], nil
+	default:
+		return nil, ErrAmbiguousCalibration
+	}
+}
+
+// SpecOf returns the serializable form of c, which is false if c is
+// not one of the serializable calibrations
+func SpecOf(c Calibration) (CalibrationSpec, bool) {
+	switch c := c.(type) {
+	case LinearCalibration:
+		return CalibrationSpec{Linear: &c}, true
+	case PiecewiseCalibration:
+		return CalibrationSpec{Piecewise: &c}, true
+	case PolynomialCalibration:
+		return CalibrationSpec{Polynomial: c}, true
+	}
+	return CalibrationSpec{}, false
+}
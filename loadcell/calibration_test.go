@@ -0,0 +1,211 @@
+package loadcell_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/loadcell"
+	"gopkg.in/yaml.v3"
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	minRaw = -(1 << 23)
+	maxRaw = 1<<23 - 1
+)
+
+// raws spans the HX711's 24 bit range
+var raws = []int64{minRaw, minRaw + 1, -1 << 22, -7222, -1, 0, 1, 7222, 1 << 22, maxRaw - 1, maxRaw}
+
+// exactNewtons evaluates a calibration in extended precision
+type exactNewtons func(raw int64) *big.Float
+
+func bigFloat(x float64) *big.Float {
+	return new(big.Float).SetPrec(256).SetFloat64(x)
+}
+
+func exactLinear(c loadcell.LinearCalibration) exactNewtons {
+	return func(raw int64) *big.Float {
+		x := bigFloat(float64(raw))
+		x.Sub(x, bigFloat(c.Offset))
+		return x.Mul(x, bigFloat(c.Slope))
+	}
+}
+
+func exactPiecewise(c loadcell.PiecewiseCalibration) exactNewtons {
+	return func(raw int64) *big.Float {
+		i := 0
+		for i < len(c.Knots)-2 && float64(raw) >= c.Knots[i+1].Raw {
+			i++
+		}
+		a, b := c.Knots[i], c.Knots[i+1]
+		slope := new(big.Float).Quo(bigFloat(b.Newtons-a.Newtons), bigFloat(b.Raw-a.Raw))
+		x := bigFloat(float64(raw))
+		x.Sub(x, bigFloat(a.Raw))
+		x.Mul(x, slope)
+		return x.Add(x, bigFloat(a.Newtons))
+	}
+}
+
+func exactPolynomial(c loadcell.PolynomialCalibration) exactNewtons {
+	return func(raw int64) *big.Float {
+		x := bigFloat(float64(raw))
+		y := bigFloat(0)
+		for i := len(c) - 1; i >= 0; i-- {
+			y.Add(y, bigFloat(c[i]))
+			y.Mul(y, x)
+		}
+		return y
+	}
+}
+
+func mustPiecewise(t *testing.T, points ...loadcell.CalibrationPoint) loadcell.PiecewiseCalibration {
+	t.Helper()
+	c, err := loadcell.NewPiecewiseCalibration(points)
+	if err != nil {
+		t.Fatalf("NewPiecewiseCalibration: %v", err)
+	}
+	return c
+}
+
+func kg(kg float64) physic.Force {
+	return physic.Force(kg * float64(physic.EarthGravity))
+}
+
+func TestCalibrationAccuracy(t *testing.T) {
+	linear := loadcell.LinearCalibration{Offset: -31000, Slope: 9.80665 / 7222}
+	steep := loadcell.LinearCalibration{Offset: 12345.5, Slope: 0.49}
+	piecewise := mustPiecewise(t,
+		loadcell.CalibrationPoint{Raw: -72220, Force: kg(-10)},
+		loadcell.CalibrationPoint{Raw: 144440, Force: kg(20)},
+		loadcell.CalibrationPoint{Raw: 505540, Force: kg(70.5)},
+	)
+	polynomial := loadcell.PolynomialCalibration{9.80665 / 7222, 2e-11}
+
+	for _, tc := range []struct {
+		name        string
+		calibration loadcell.InvertibleCalibration
+		exact       exactNewtons
+		// maxForceErr bounds the error of ToForce in nN
+		maxForceErr float64
+		// maxRawErr bounds the error of ToRaw(ToForce(raw))
+		maxRawErr int64
+	}{
+		{"linear", linear, exactLinear(linear), 1, 0},
+		{"linear steep", steep, exactLinear(steep), 1, 0},
+		{"truesun", loadcell.TrueSun400Slow.(loadcell.InvertibleCalibration), exactLinear(loadcell.TrueSun400Slow.(loadcell.LinearCalibration)), 1, 0},
+		{"piecewise", piecewise, exactPiecewise(piecewise), 1, 0},
+		{"polynomial", polynomial, exactPolynomial(polynomial), 1, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, raw := range raws {
+				f := tc.calibration.ToForce(raw)
+
+				want := tc.exact(raw)
+				want.Mul(want, bigFloat(float64(physic.Newton)))
+				diff, _ := new(big.Float).Sub(bigFloat(float64(f)), want).Float64()
+				if math.Abs(diff) > tc.maxForceErr {
+					t.Errorf("ToForce(%d) = %dnN, off by %.3gnN", raw, int64(f), diff)
+				}
+
+				got := tc.calibration.ToRaw(f)
+				if d := got - raw; d > tc.maxRawErr || -d > tc.maxRawErr {
+					t.Errorf("ToRaw(ToForce(%d)) = %d, off by %d", raw, got, d)
+				}
+			}
+		})
+	}
+}
+
+func TestCalibrationSaturates(t *testing.T) {
+	c := loadcell.LinearCalibration{Slope: 1e12}
+	if f := c.ToForce(maxRaw); f != physic.Force(math.MaxInt64) {
+		t.Errorf("ToForce(%d) = %v, want saturation", int64(maxRaw), f)
+	}
+	if f := c.ToForce(minRaw); f != physic.Force(math.MinInt64) {
+		t.Errorf("ToForce(%d) = %v, want saturation", int64(minRaw), f)
+	}
+	shallow := loadcell.LinearCalibration{Slope: 1e-12}
+	if raw := shallow.ToRaw(physic.KiloNewton); raw != maxRaw {
+		t.Errorf("ToRaw(1kN) = %d, want %d", raw, int64(maxRaw))
+	}
+}
+
+func TestCalibrationSpecRoundTrip(t *testing.T) {
+	piecewise := mustPiecewise(t,
+		loadcell.CalibrationPoint{Raw: 72220, Force: kg(10)},
+		loadcell.CalibrationPoint{Raw: 505540, Force: kg(70.5)},
+	)
+	for _, tc := range []struct {
+		name        string
+		calibration loadcell.Calibration
+	}{
+		{"linear", loadcell.LinearCalibration{Offset: -31000.25, Slope: 9.80665 / 7222}},
+		{"piecewise", piecewise},
+		{"polynomial", loadcell.PolynomialCalibration{9.80665 / 7222, 2e-11}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, ok := loadcell.SpecOf(tc.calibration)
+			if !ok {
+				t.Fatalf("no spec for %T", tc.calibration)
+			}
+			for _, format := range []struct {
+				name      string
+				marshal   func(interface{}) ([]byte, error)
+				unmarshal func([]byte, interface{}) error
+			}{
+				{"json", json.Marshal, json.Unmarshal},
+				{"yaml", yaml.Marshal, yaml.Unmarshal},
+			} {
+				b, err := format.marshal(spec)
+				if err != nil {
+					t.Fatalf("%s: marshal: %v", format.name, err)
+				}
+				var got loadcell.CalibrationSpec
+				if err := format.unmarshal(b, &got); err != nil {
+					t.Fatalf("%s: unmarshal: %v", format.name, err)
+				}
+				c, err := got.Calibration()
+				if err != nil {
+					t.Fatalf("%s: %v", format.name, err)
+				}
+				if !reflect.DeepEqual(c, tc.calibration) {
+					t.Errorf("%s: round trip through\n%s\ngave %#v, want %#v", format.name, b, c, tc.calibration)
+				}
+			}
+		})
+	}
+}
+
+func TestCalibrationSpecErrors(t *testing.T) {
+	if _, err := (loadcell.CalibrationSpec{}).Calibration(); err != loadcell.ErrNoCalibration {
+		t.Errorf("empty spec gave %v, want %v", err, loadcell.ErrNoCalibration)
+	}
+	spec := loadcell.CalibrationSpec{
+		Linear:     &loadcell.LinearCalibration{Slope: 1},
+		Polynomial: loadcell.PolynomialCalibration{1},
+	}
+	if _, err := spec.Calibration(); err != loadcell.ErrAmbiguousCalibration {
+		t.Errorf("spec with two calibrations gave %v, want %v", err, loadcell.ErrAmbiguousCalibration)
+	}
+}
+
+func TestAtGain(t *testing.T) {
+	c := loadcell.LinearCalibration{Offset: -31000, Slope: 9.80665 / 7222}
+	b := loadcell.AtGain(c, hx711.ChannelA128, hx711.ChannelB32).(loadcell.InvertibleCalibration)
+	for _, raw := range []int64{-1 << 20, -7222, 0, 7222, 1 << 20} {
+		if got, want := b.ToForce(raw), c.ToForce(4*raw); got != want {
+			t.Errorf("ToForce(%d) at B32 = %v, want %v", raw, got, want)
+		}
+		if got := b.ToRaw(b.ToForce(raw)); got != raw {
+			t.Errorf("ToRaw(ToForce(%d)) at B32 = %d", raw, got)
+		}
+	}
+	if got := loadcell.AtGain(c, hx711.ChannelA128, hx711.ChannelA128); got != loadcell.Calibration(c) {
+		t.Errorf("AtGain changed a calibration at its own gain to %v", got)
+	}
+}
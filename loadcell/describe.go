@@ -0,0 +1,16 @@
+package loadcell
+
+// Describer is implemented by sensors which can describe how their
+// readings are produced, such as their calibration and tare, for the
+// metadata of recordings
+type Describer interface {
+	Describe() map[string]string
+}
+
+// Describe returns the description of s, or nil if it has none
+func Describe(s Sensor) map[string]string {
+	if d, ok := s.(Describer); ok {
+		return d.Describe()
+	}
+	return nil
+}
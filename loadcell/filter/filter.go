@@ -0,0 +1,82 @@
+// Package filter smooths force samples for display and control. A
+// filtered sample keeps its measured force, so recorders which are
+// given filtered samples still record the raw data.
+package filter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+// Filter smooths a sequence of force samples
+type Filter interface {
+	// Next adds a sample and returns it with its filtered force. The
+	// input to the filter is the sample's Filtered force, so that
+	// filters can be chained.
+	Next(fs loadcell.ForceSample) loadcell.ForceSample
+	// Reset forgets all samples, as the force jumps after a tare
+	Reset()
+}
+
+type chain []Filter
+
+// Chain applies each of the filters in turn
+func Chain(filters ...Filter) Filter {
+	return chain(filters)
+}
+
+func (c chain) Next(fs loadcell.ForceSample) loadcell.ForceSample {
+	for _, f := range c {
+		fs = f.Next(fs)
+	}
+	return fs
+}
+
+func (c chain) Reset() {
+	for _, f := range c {
+		f.Reset()
+	}
+}
+
+type filteredSensor struct {
+	loadcell.Sensor
+
+	mu     sync.Mutex
+	filter Filter
+}
+
+// Sensor filters every sample read from s. The filter is reset
+// whenever s is tared.
+func Sensor(s loadcell.Sensor, f Filter) loadcell.Sensor {
+	return &filteredSensor{
+		Sensor: s,
+		filter: f,
+	}
+}
+
+func (s *filteredSensor) Tare(ctx context.Context, samples int) (loadcell.TareResult, error) {
+	r, err := s.Sensor.Tare(ctx, samples)
+	if err == nil {
+		s.mu.Lock()
+		s.filter.Reset()
+		s.mu.Unlock()
+	}
+	return r, err
+}
+
+// Describe implements loadcell.Describer
+func (s *filteredSensor) Describe() map[string]string {
+	return loadcell.Describe(s.Sensor)
+}
+
+func (s *filteredSensor) Read(ctx context.Context) (loadcell.ForceSample, error) {
+	fs, err := s.Sensor.Read(ctx)
+	if err != nil {
+		return fs, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.filter.Next(fs), nil
+}
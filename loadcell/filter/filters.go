@@ -0,0 +1,219 @@
+package filter
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+func input(fs loadcell.ForceSample) float64 {
+	return float64(fs.Filtered())
+}
+
+func output(fs loadcell.ForceSample, v float64) loadcell.ForceSample {
+	return fs.WithFiltered(physic.Force(math.Round(v)))
+}
+
+// window holds the samples received within a duration of the latest
+type window struct {
+	dur     time.Duration
+	samples []loadcell.ForceSample
+}
+
+func (w *window) add(fs loadcell.ForceSample) (dropped []loadcell.ForceSample) {
+	w.samples = append(w.samples, fs)
+	i := 0
+	for fs.Time.Sub(w.samples[i].Time) >= w.dur && i < len(w.samples)-1 {
+		i++
+	}
+	dropped = w.samples[:i]
+	w.samples = w.samples[i:]
+	return dropped
+}
+
+type movingAverage struct {
+	window
+	sum float64
+}
+
+// MovingAverage averages the samples received within the window. Its
+// latency is half the window.
+func MovingAverage(window time.Duration) Filter {
+	m := &movingAverage{}
+	m.dur = window
+	return m
+}
+
+func (m *movingAverage) Next(fs loadcell.ForceSample) loadcell.ForceSample {
+	m.sum += input(fs)
+	for _, old := range m.add(fs) {
+		m.sum -= input(old)
+	}
+	return output(fs, m.sum/float64(len(m.samples)))
+}
+
+func (m *movingAverage) Reset() {
+	m.samples = nil
+	m.sum = 0
+}
+
+type median struct {
+	window
+	sorted []float64
+}
+
+// Median takes the median of the samples received within the window,
+// which rejects spikes better than MovingAverage. Its latency is half
+// the window.
+func Median(window time.Duration) Filter {
+	m := &median{}
+	m.dur = window
+	return m
+}
+
+func (m *median) Next(fs loadcell.ForceSample) loadcell.ForceSample {
+	m.add(fs)
+	m.sorted = m.sorted[:0]
+	for _, s := range m.samples {
+		m.sorted = append(m.sorted, input(s))
+	}
+	sort.Float64s(m.sorted)
+	n := len(m.sorted)
+	med := m.sorted[n/2]
+	if n%2 == 0 {
+		med = (m.sorted[n/2-1] + med) / 2
+	}
+	return output(fs, med)
+}
+
+func (m *median) Reset() {
+	m.samples = nil
+}
+
+type exponential struct {
+	timeConstant time.Duration
+	prev         time.Time
+	y            float64
+}
+
+// Exponential smooths samples with an exponential moving average of
+// the given time constant, which is also its latency. Samples are
+// weighted by the time between them, so irregular sampling is fine.
+func Exponential(timeConstant time.Duration) Filter {
+	return &exponential{timeConstant: timeConstant}
+}
+
+func (e *exponential) Next(fs loadcell.ForceSample) loadcell.ForceSample {
+	x := input(fs)
+	if e.prev.IsZero() || e.timeConstant <= 0 {
+		e.y = x
+	} else {
+		alpha := 1 - math.Exp(-float64(fs.Time.Sub(e.prev))/float64(e.timeConstant))
+		e.y += alpha * (x - e.y)
+	}
+	e.prev = fs.Time
+	return output(fs, e.y)
+}
+
+func (e *exponential) Reset() {
+	e.prev = time.Time{}
+}
+
+type kalman struct {
+	// q is the variance of the change in force per second and r the
+	// variance of the measurement noise
+	q, r float64
+	prev time.Time
+	x, p float64
+}
+
+// Kalman estimates the force with a one dimensional Kalman filter.
+// processNoise is the typical change in the true force over a second
+// and measurementNoise is the typical noise in a reading; the larger
+// their ratio, the lower the latency and the less smoothing.
+func Kalman(processNoise, measurementNoise physic.Force) Filter {
+	return &kalman{
+		q: float64(processNoise) * float64(processNoise),
+		r: float64(measurementNoise) * float64(measurementNoise),
+	}
+}
+
+func (k *kalman) Next(fs loadcell.ForceSample) loadcell.ForceSample {
+	z := input(fs)
+	if k.prev.IsZero() {
+		k.x, k.p = z, k.r
+	} else {
+		k.p += k.q * fs.Time.Sub(k.prev).Seconds()
+		gain := k.p / (k.p + k.r)
+		k.x += gain * (z - k.x)
+		k.p *= 1 - gain
+	}
+	k.prev = fs.Time
+	return output(fs, k.x)
+}
+
+func (k *kalman) Reset() {
+	k.prev = time.Time{}
+}
+
+type butterworth struct {
+	cutoff physic.Frequency
+	// interval is a running estimate of the sample interval
+	interval time.Duration
+	prev     time.Time
+	x1, x2   float64
+	y1, y2   float64
+}
+
+// Butterworth is a second order low-pass Butterworth filter, which
+// passes changes slower than the cutoff frequency with little
+// distortion. The sample rate is measured from the samples. Its
+// latency is √2/2π over the cutoff frequency, about 110ms at 2Hz.
+func Butterworth(cutoff physic.Frequency) Filter {
+	return &butterworth{cutoff: cutoff}
+}
+
+func (b *butterworth) Next(fs loadcell.ForceSample) loadcell.ForceSample {
+	x := input(fs)
+	if b.prev.IsZero() {
+		// start from steady state to avoid ringing
+		b.x1, b.x2, b.y1, b.y2 = x, x, x, x
+		b.prev = fs.Time
+		return output(fs, x)
+	}
+	dt := fs.Time.Sub(b.prev)
+	b.prev = fs.Time
+	if b.interval == 0 {
+		b.interval = dt
+	} else {
+		b.interval += (dt - b.interval) / 8
+	}
+
+	rate := float64(time.Second) / float64(b.interval)
+	fc := float64(b.cutoff) / float64(physic.Hertz)
+	if b.interval <= 0 || fc <= 0 || fc >= rate/2 {
+		// the cutoff is beyond what the sample rate can represent
+		b.x2, b.x1 = b.x1, x
+		b.y2, b.y1 = b.y1, x
+		return output(fs, x)
+	}
+	k := math.Tan(math.Pi * fc / rate)
+	norm := 1 / (1 + math.Sqrt2*k + k*k)
+	b0 := k * k * norm
+	b1 := 2 * b0
+	a1 := 2 * (k*k - 1) * norm
+	a2 := (1 - math.Sqrt2*k + k*k) * norm
+
+	y := b0*x + b1*b.x1 + b0*b.x2 - a1*b.y1 - a2*b.y2
+	b.x2, b.x1 = b.x1, x
+	b.y2, b.y1 = b.y1, y
+	return output(fs, y)
+}
+
+func (b *butterworth) Reset() {
+	b.prev = time.Time{}
+	b.interval = 0
+}
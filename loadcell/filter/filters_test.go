@@ -0,0 +1,145 @@
+package filter_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"github.com/chewr/tension-scale/loadcell/filter"
+	"periph.io/x/periph/conn/physic"
+)
+
+// interval is the interval between samples at 80SPS
+const interval = 12500 * time.Microsecond
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// step is the force of the step in the step response
+const step = 100 * physic.Newton
+
+// stepResponse feeds f a second of no force then two seconds of step
+// and returns the filtered forces from the step on
+func stepResponse(f filter.Filter) []physic.Force {
+	var response []physic.Force
+	for i := 0; i < 240; i++ {
+		fs := loadcell.ForceSample{Time: start.Add(time.Duration(i) * interval)}
+		if i >= 80 {
+			fs.Force = step
+		}
+		out := f.Next(fs)
+		if out.Force != fs.Force {
+			panic("filter changed the measured force")
+		}
+		if i >= 80 {
+			response = append(response, out.Filtered())
+		}
+	}
+	return response
+}
+
+// rise returns how long after the step the response reached the
+// fraction of the step
+func rise(response []physic.Force, fraction float64) time.Duration {
+	for i, f := range response {
+		if float64(f) >= fraction*float64(step) {
+			return time.Duration(i) * interval
+		}
+	}
+	return time.Duration(len(response)) * interval
+}
+
+func TestStepResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		filter filter.Filter
+		// latency is when the response reaches the fraction of the
+		// step, to within a sample
+		fraction float64
+		latency  time.Duration
+		// overshoot is the most the response may exceed the step by
+		overshoot physic.Force
+	}{
+		// half the window
+		{"MovingAverage", filter.MovingAverage(200 * time.Millisecond), 0.5, 100 * time.Millisecond, 0},
+		{"Median", filter.Median(200 * time.Millisecond), 0.5, 100 * time.Millisecond, 0},
+		// the time constant
+		{"Exponential", filter.Exponential(100 * time.Millisecond), 1 - 1/math.E, 100 * time.Millisecond, 0},
+		// √2/2π over the cutoff
+		{"Butterworth", filter.Butterworth(2 * physic.Hertz), 0.5, 110 * time.Millisecond, 5 * physic.Newton},
+		// depends on the noise, and on the sample rate
+		{"Kalman", filter.Kalman(filter.DefaultKalmanProcessNoise, filter.DefaultKalmanMeasurementNoise), 0.5, 50 * time.Millisecond, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			response := stepResponse(tc.filter)
+			if d := rise(response, tc.fraction); d < tc.latency-interval || d > tc.latency+interval {
+				t.Errorf("reached %.0f%% of the step after %v, want %v", 100*tc.fraction, d, tc.latency)
+			}
+			for i, f := range response {
+				if f > step+tc.overshoot || f < 0 {
+					t.Fatalf("sample %d after the step: got %v, want between 0 and %v", i, f, step+tc.overshoot)
+				}
+			}
+			// settled by the end
+			if f := response[len(response)-1]; math.Abs(float64(f-step)) > float64(physic.Newton) {
+				t.Errorf("settled at %v, want %v", f, step)
+			}
+		})
+	}
+}
+
+func TestKalmanProcessNoise(t *testing.T) {
+	slow := rise(stepResponse(filter.Kalman(physic.Newton, 2*physic.Newton)), 0.5)
+	fast := rise(stepResponse(filter.Kalman(10*physic.Newton, 2*physic.Newton)), 0.5)
+	if fast >= slow {
+		t.Errorf("reached half the step after %v with more process noise, want sooner than %v", fast, slow)
+	}
+}
+
+func TestMedianRejectsSpikes(t *testing.T) {
+	f := filter.Median(100 * time.Millisecond)
+	for i := 0; i < 40; i++ {
+		fs := loadcell.ForceSample{Force: step, Time: start.Add(time.Duration(i) * interval)}
+		if i == 20 {
+			fs.Force = 10 * step
+		}
+		if got := f.Next(fs).Filtered(); got != step {
+			t.Fatalf("sample %d: got %v, want %v", i, got, step)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		filter filter.Filter
+	}{
+		{"MovingAverage", filter.MovingAverage(200 * time.Millisecond)},
+		{"Median", filter.Median(200 * time.Millisecond)},
+		{"Exponential", filter.Exponential(100 * time.Millisecond)},
+		{"Butterworth", filter.Butterworth(2 * physic.Hertz)},
+		{"Kalman", filter.Kalman(filter.DefaultKalmanProcessNoise, filter.DefaultKalmanMeasurementNoise)},
+		{"Chain", filter.Chain(filter.Median(200*time.Millisecond), filter.Butterworth(2*physic.Hertz))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stepResponse(tc.filter)
+			tc.filter.Reset()
+			// forgets the step, as after a tare
+			fs := loadcell.ForceSample{Time: start.Add(time.Hour)}
+			if got := tc.filter.Next(fs).Filtered(); got != 0 {
+				t.Errorf("got %v after Reset, want 0", got)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	// each filter smooths the output of the last, so the chain lags
+	// more than either
+	average := rise(stepResponse(filter.MovingAverage(200*time.Millisecond)), 0.5)
+	exponential := rise(stepResponse(filter.Exponential(100*time.Millisecond)), 0.5)
+	chained := rise(stepResponse(filter.Chain(filter.MovingAverage(200*time.Millisecond), filter.Exponential(100*time.Millisecond))), 0.5)
+	if chained <= average || chained <= exponential {
+		t.Errorf("chain reached half the step after %v, want later than %v and %v", chained, average, exponential)
+	}
+}
@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+// The noise parameters of a kalman filter given without parameters
+const (
+	DefaultKalmanProcessNoise     = 3 * physic.Newton
+	DefaultKalmanMeasurementNoise = 2 * physic.Newton
+)
+
+// Parse returns the filter described by spec, which is a comma
+// separated chain of filters, each a name with colon separated
+// parameters:
+//
+//	average:<window>
+//	median:<window>
+//	exponential:<time constant>
+//	kalman[:<process noise>:<measurement noise>]
+//	butterworth:<cutoff>
+//
+// for example "median:150ms,butterworth:2Hz". An empty spec or "none"
+// returns nil.
+func Parse(spec string) (Filter, error) {
+	if spec == "" || spec == "none" {
+		return nil, nil
+	}
+	var filters []Filter
+	for _, s := range strings.Split(spec, ",") {
+		f, err := parseOne(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return Chain(filters...), nil
+}
+
+func parseOne(spec string) (Filter, error) {
+	parts := strings.Split(spec, ":")
+	name, params := parts[0], parts[1:]
+	bad := func(err error) error {
+		if err != nil {
+			return fmt.Errorf("bad filter spec %q: %v", spec, err)
+		}
+		return fmt.Errorf("bad filter spec %q", spec)
+	}
+	switch name {
+	case "average", "median", "exponential":
+		if len(params) != 1 {
+			return nil, bad(nil)
+		}
+		d, err := time.ParseDuration(params[0])
+		if err != nil {
+			return nil, bad(err)
+		}
+		switch name {
+		case "average":
+			return MovingAverage(d), nil
+		case "median":
+			return Median(d), nil
+		default:
+			return Exponential(d), nil
+		}
+	case "kalman":
+		switch len(params) {
+		case 0:
+			return Kalman(DefaultKalmanProcessNoise, DefaultKalmanMeasurementNoise), nil
+		case 2:
+			var q, r physic.Force
+			if err := q.Set(params[0]); err != nil {
+				return nil, bad(err)
+			}
+			if err := r.Set(params[1]); err != nil {
+				return nil, bad(err)
+			}
+			return Kalman(q, r), nil
+		}
+	case "butterworth":
+		if len(params) != 1 {
+			return nil, bad(nil)
+		}
+		var fc physic.Frequency
+		if err := fc.Set(params[0]); err != nil {
+			return nil, bad(err)
+		}
+		return Butterworth(fc), nil
+	}
+	return nil, bad(nil)
+}
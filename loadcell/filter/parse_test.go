@@ -0,0 +1,55 @@
+package filter_test
+
+import (
+	"testing"
+
+	"github.com/chewr/tension-scale/loadcell/filter"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		// none is whether the spec is of no filter
+		none bool
+		err  bool
+	}{
+		{spec: "", none: true},
+		{spec: "none", none: true},
+		{spec: "average:200ms"},
+		{spec: "median:150ms"},
+		{spec: "exponential:100ms"},
+		{spec: "kalman"},
+		{spec: "kalman:3N:2N"},
+		{spec: "butterworth:2Hz"},
+		{spec: "median:150ms,butterworth:2Hz"},
+		{spec: "median:150ms, butterworth:2Hz"},
+
+		{spec: "average", err: true},
+		{spec: "average:", err: true},
+		{spec: "average:200", err: true},
+		{spec: "median:150ms:300ms", err: true},
+		{spec: "exponential:fast", err: true},
+		{spec: "kalman:3N", err: true},
+		{spec: "kalman:3N:2N:1N", err: true},
+		{spec: "kalman:3N:loud", err: true},
+		{spec: "butterworth", err: true},
+		{spec: "butterworth:2Hz:4", err: true},
+		{spec: "lowpass:2Hz", err: true},
+		{spec: "median:150ms,", err: true},
+		{spec: "median:150ms,none", err: true},
+		{spec: "Median:150ms", err: true},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			f, err := filter.Parse(tc.spec)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			if err != nil && f != nil {
+				t.Errorf("got a filter with the error")
+			}
+			if !tc.err && (f == nil) != tc.none {
+				t.Errorf("got filter %v, want none %v", f, tc.none)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package loadcell
+
+import (
+	"errors"
+	"math"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+var (
+	ErrNotEnoughPoints   = errors.New("not enough calibration points for the fit")
+	ErrUnsupportedDegree = errors.New("only linear and quadratic fits are supported")
+	ErrDegenerateFit     = errors.New("calibration points do not determine a fit")
+)
+
+// PolynomialCalibration converts tared readings to force in newtons
+// with a polynomial in the raw value. The coefficients are for raw,
+// raw², and so on; there is no constant term since a tared reading
+// of zero is zero force.
+type PolynomialCalibration []float64
+
+func (c PolynomialCalibration) ToForce(raw int64) physic.Force {
+	return toForce(c.eval(float64(raw)))
+}
+
+// eval returns the force in newtons at x
+func (c PolynomialCalibration) eval(x float64) float64 {
+	var y float64
+	for i := len(c) - 1; i >= 0; i-- {
+		y = (y + c[i]) * x
+	}
+	return y
+}
+
+func (c PolynomialCalibration) derivative(x float64) float64 {
+	var dy float64
+	for i := len(c) - 1; i >= 0; i-- {
+		dy = dy*x + float64(i+1)*c[i]
+	}
+	return dy
+}
+
+// ToRaw finds the reading by Newton's method, starting from the
+// linear term, so the polynomial should be monotonic over the range
+// of interest
+func (c PolynomialCalibration) ToRaw(f physic.Force) int64 {
+	if len(c) == 0 || c[0] == 0 {
+		return 0
+	}
+	y := newtons(f)
+	x := y / c[0]
+	for i := 0; i < 32; i++ {
+		dy := c.derivative(x)
+		if dy == 0 {
+			break
+		}
+		step := (c.eval(x) - y) / dy
+		x -= step
+		if math.Abs(step) < 1e-3 {
+			break
+		}
+	}
+	return toRaw(x)
+}
+
+// CalibrationPoint pairs a tared reading with the known force which
+// produced it
+type CalibrationPoint struct {
+	Raw   float64
+	Force physic.Force
+}
+
+// Fit is the result of fitting a calibration to a set of points
+type Fit struct {
+	Calibration PolynomialCalibration
+	// Residuals are the measured minus the known force at each point
+	Residuals []physic.Force
+	// LinearityError is the largest residual as a fraction of the
+	// largest known force
+	LinearityError float64
+}
+
+// FitCalibration finds the least squares calibration of the given
+// degree, 1 for linear or 2 for quadratic, through the points
+func FitCalibration(points []CalibrationPoint, degree int) (Fit, error) {
+	if degree < 1 || degree > 2 {
+		return Fit{}, ErrUnsupportedDegree
+	}
+	if len(points) < degree {
+		return Fit{}, ErrNotEnoughPoints
+	}
+
+	// normal equations for force = a·raw + b·raw²
+	var xx, xx2, x2x2, xy, x2y float64
+	for _, p := range points {
+		x, x2, y := p.Raw, p.Raw*p.Raw, float64(p.Force)/float64(physic.Newton)
+		xx += x * x
+		xx2 += x * x2
+		x2x2 += x2 * x2
+		xy += x * y
+		x2y += x2 * y
+	}
+	var c PolynomialCalibration
+	switch degree {
+	case 1:
+		if xx == 0 {
+			return Fit{}, ErrDegenerateFit
+		}
+		c = PolynomialCalibration{xy / xx}
+	case 2:
+		det := xx*x2x2 - xx2*xx2
+		if det == 0 {
+			return Fit{}, ErrDegenerateFit
+		}
+		c = PolynomialCalibration{
+			(xy*x2x2 - x2y*xx2) / det,
+			(xx*x2y - xx2*xy) / det,
+		}
+	}
+
+	fit := Fit{
+		Calibration: c,
+		Residuals:   make([]physic.Force, len(points)),
+	}
+	var maxResidual, maxForce physic.Force
+	for i, p := range points {
+		r := c.ToForce(int64(math.Round(p.Raw))) - p.Force
+		fit.Residuals[i] = r
+		if r < 0 {
+			r = -r
+		}
+		if r > maxResidual {
+			maxResidual = r
+		}
+		if f := p.Force; f > maxForce {
+			maxForce = f
+		} else if -f > maxForce {
+			maxForce = -f
+		}
+	}
+	if maxForce > 0 {
+		fit.LinearityError = float64(maxResidual) / float64(maxForce)
+	}
+	return fit, nil
+}
@@ -2,47 +2,157 @@ package loadcell
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
 	"sync"
 
 	"github.com/chewr/tension-scale/hx711"
+	"periph.io/x/periph/conn/physic"
 )
 
 type hx711Sensor struct {
 	// immutable
-	mu          sync.Mutex
-	hx          hx711.V2
-	calibration Calibration
+	mu              sync.Mutex
+	hx              hx711.V2
+	calibration     Calibration
+	tareTolerance   physic.Force
+	loadedThreshold physic.Force
+	// unloaded is the reading with nothing on the board, if
+	// hasUnloaded
+	unloaded    int64
+	hasUnloaded bool
 
 	// mutable
 	tare int64
+	// tared is set once a tare has been accepted
+	tared bool
 }
 
-func NewHx711(hx hx711.V2, calibration Calibration) Sensor {
-	return &hx711Sensor{
-		hx:          hx,
-		calibration: calibration,
+func NewHx711(hx hx711.V2, calibration Calibration, opts ...Option) Sensor {
+	s := &hx711Sensor{
+		hx:              hx,
+		calibration:     calibration,
+		tareTolerance:   DefaultTareTolerance,
+		loadedThreshold: DefaultLoadedThreshold,
 	}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+	return s
 }
 
-func (s *hx711Sensor) Tare(ctx context.Context, samples int) error {
+// Tare waits for a window of samples readings whose spread is within
+// the tare tolerance, discarding outliers and bad reads, and zeroes
+// the sensor at their mean. If the readings never settle, or settle
+// far from the previous tare, or on the first tare far from the
+// unloaded reading if there is one, the board is taken to be loaded
+// and a
+// *BoardLoadedError is returned.
+func (s *hx711Sensor) Tare(ctx context.Context, samples int) (TareResult, error) {
 	if samples <= 0 {
-		return ErrNotEnoughSamples
+		return TareResult{}, ErrNotEnoughSamples
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	var total int64 = 0
-	for i := 0; i < samples; i++ {
-		r, err := s.hx.Read(ctx)
+
+	w := &window{size: samples}
+	badReads := 0
+	var r TareResult
+	for reads := 0; reads < tareWindows*samples; {
+		ts, err := s.hx.Read(ctx)
 		switch err {
 		case nil:
 		case hx711.ErrBadRead:
+			badReads++
+			if badReads > tareWindows*samples {
+				return TareResult{}, ErrNotEnoughSamples
+			}
+			continue
 		default:
-			return err
+			return TareResult{}, err
+		}
+		reads++
+		w.add(float64(ts.Raw))
+		if !w.full() {
+			continue
+		}
+		r = w.stats()
+		r.Rejected += badReads
+		if s.spread(r.StdDev) <= s.tareTolerance {
+			break
 		}
-		total += int64(r.Raw)
 	}
-	s.tare = total / int64(samples)
-	return nil
+	if !w.full() {
+		return TareResult{}, ErrNotEnoughSamples
+	}
+	stddev := s.spread(r.StdDev)
+	if stddev > s.tareTolerance {
+		return r, &BoardLoadedError{StdDev: stddev}
+	}
+
+	// keep the calibration's own offset, so that the tared reading is
+	// the reading the calibration expects at zero force
+	zero := s.zero()
+	mean := int64(math.Round(r.Mean))
+	if s.tared || s.hasUnloaded {
+		// the reading at the previous tare, or failing that the
+		// unloaded reading
+		ref := s.unloaded
+		if s.tared {
+			ref = s.tare + zero
+		}
+		load := s.calibration.ToForce(mean-ref+zero) - s.calibration.ToForce(zero)
+		if load > s.loadedThreshold || -load > s.loadedThreshold {
+			return r, &BoardLoadedError{Load: load, StdDev: stddev}
+		}
+	}
+	s.tare = mean - zero
+	s.tared = true
+	return r, nil
+}
+
+func (s *hx711Sensor) zero() int64 {
+	if c, ok := s.calibration.(InvertibleCalibration); ok {
+		return c.ToRaw(0)
+	}
+	return 0
+}
+
+// spread converts a standard deviation in raw units to force
+func (s *hx711Sensor) spread(raw float64) physic.Force {
+	zero := s.zero()
+	f := s.calibration.ToForce(zero+int64(math.Round(raw))) - s.calibration.ToForce(zero)
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// Describe implements Describer
+func (s *hx711Sensor) Describe() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := map[string]string{
+		"sensor":      s.hx.String(),
+		"calibration": fmt.Sprintf("%T", s.calibration),
+		"tare":        strconv.FormatInt(s.tare, 10),
+	}
+	if spec, ok := SpecOf(s.calibration); ok {
+		if b, err := json.Marshal(spec); err == nil {
+			d["calibration"] = string(b)
+		}
+	}
+	if g, ok := s.hx.(hx711.GainReporter); ok {
+		d["gain"] = g.Gain().String()
+	}
+	if r, ok := s.hx.(hx711.SampleRateDetector); ok {
+		if rate, _, ok := r.SampleRate(); ok {
+			d["sample-rate"] = fmt.Sprintf("%dSPS", rate)
+		}
+	}
+	return d
 }
 
 func (s *hx711Sensor) Reset(ctx context.Context) error {
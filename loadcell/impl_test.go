@@ -0,0 +1,44 @@
+package loadcell_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/hx711"
+	"github.com/chewr/tension-scale/hx711/sim"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+// offset is the reading of an unloaded board far from raw 0, about
+// 70kg by the TrueSun calibration
+const offset = 500000
+
+func TestFirstTare(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		kg     int32
+		opts   []loadcell.Option
+		loaded bool
+	}{
+		{"unloaded", 0, nil, false},
+		// without an unloaded reading, any steady load is accepted
+		{"loaded without unloaded reading", 20, nil, false},
+		{"unloaded as measured", 0, []loadcell.Option{loadcell.WithUnloadedReading(offset)}, false},
+		{"drifted", 1, []loadcell.Option{loadcell.WithUnloadedReading(offset)}, false},
+		{"loaded", 20, []loadcell.Option{loadcell.WithUnloadedReading(offset)}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			hx := sim.New(sim.Constant(tc.kg*7222), sim.WithOffset(offset), sim.WithSampleRate(hx711.Rate80SPS))
+			defer hx.Halt()
+
+			_, err := loadcell.NewHx711(hx, loadcell.TrueSun400Slow, tc.opts...).Tare(ctx, 5)
+			_, loaded := err.(*loadcell.BoardLoadedError)
+			if loaded != tc.loaded || (err != nil && !loaded) {
+				t.Errorf("Tare with %dkg on the board: got %v, want loaded %v", tc.kg, err, tc.loaded)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package loadcell
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+type multiSensor struct {
+	cells []Sensor
+}
+
+// NewMulti combines several load cells, each with its own amplifier,
+// into a single Sensor which reads their total force. The force on
+// each cell is reported in ForceSample.Cells, in the order given.
+func NewMulti(cells ...Sensor) Sensor {
+	return &multiSensor{
+		cells: cells,
+	}
+}
+
+// each runs fn on every cell at the same time, returning the first
+// error in cell order
+func (s *multiSensor) each(fn func(i int, cell Sensor) error) error {
+	errs := make([]error, len(s.cells))
+	var wg sync.WaitGroup
+	for i, cell := range s.cells {
+		wg.Add(1)
+		go func(i int, cell Sensor) {
+			defer wg.Done()
+			errs[i] = fn(i, cell)
+		}(i, cell)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Describe implements Describer, prefixing the description of each
+// cell with its index
+func (s *multiSensor) Describe() map[string]string {
+	d := map[string]string{}
+	for i, cell := range s.cells {
+		for k, v := range Describe(cell) {
+			d[fmt.Sprintf("cell%d.%s", i, k)] = v
+		}
+	}
+	return d
+}
+
+// Tare tares every cell at the same time. The combined result sums
+// the cells' readings, treating their noise as independent.
+func (s *multiSensor) Tare(ctx context.Context, samples int) (TareResult, error) {
+	results := make([]TareResult, len(s.cells))
+	err := s.each(func(i int, cell Sensor) (err error) {
+		results[i], err = cell.Tare(ctx, samples)
+		return err
+	})
+	out := TareResult{
+		Cells: results,
+	}
+	var variance float64
+	for i, r := range results {
+		out.Mean += r.Mean
+		variance += r.StdDev * r.StdDev
+		out.Rejected += r.Rejected
+		if i == 0 || r.Samples < out.Samples {
+			out.Samples = r.Samples
+		}
+	}
+	out.StdDev = math.Sqrt(variance)
+	return out, err
+}
+
+func (s *multiSensor) Reset(ctx context.Context) error {
+	return s.each(func(_ int, cell Sensor) error {
+		return cell.Reset(ctx)
+	})
+}
+
+func (s *multiSensor) Halt() error {
+	return s.each(func(_ int, cell Sensor) error {
+		return cell.Halt()
+	})
+}
+
+// Read reads every cell and sums the result. The sample is timed
+// by the last of the cells to be read.
+func (s *multiSensor) Read(ctx context.Context) (ForceSample, error) {
+	samples := make([]ForceSample, len(s.cells))
+	if err := s.each(func(i int, cell Sensor) (err error) {
+		samples[i], err = cell.Read(ctx)
+		return err
+	}); err != nil {
+		return ForceSample{}, err
+	}
+	out := ForceSample{
+		Cells: make([]physic.Force, len(samples)),
+	}
+	for i, r := range samples {
+		out.Force += r.Force
+		out.Cells[i] = r.Force
+		if r.Time.After(out.Time) {
+			out.Time = r.Time
+		}
+	}
+	return out, nil
+}
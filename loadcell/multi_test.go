@@ -0,0 +1,204 @@
+package loadcell_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// fakeCell is a load cell which reads a fixed force. Its Tare waits
+// until every cell sharing the barrier is taring.
+type fakeCell struct {
+	force   physic.Force
+	time    time.Time
+	tare    loadcell.TareResult
+	tareErr error
+	barrier *barrier
+
+	mu     sync.Mutex
+	resets int
+	halts  int
+}
+
+func (c *fakeCell) Tare(ctx context.Context, _ int) (loadcell.TareResult, error) {
+	if c.barrier != nil {
+		if err := c.barrier.wait(ctx); err != nil {
+			return loadcell.TareResult{}, err
+		}
+	}
+	return c.tare, c.tareErr
+}
+
+func (c *fakeCell) Reset(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resets++
+	return nil
+}
+
+func (c *fakeCell) Halt() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.halts++
+	return nil
+}
+
+func (c *fakeCell) Read(context.Context) (loadcell.ForceSample, error) {
+	return loadcell.ForceSample{Force: c.force, Time: c.time}, nil
+}
+
+func (c *fakeCell) Describe() map[string]string {
+	return map[string]string{"force": c.force.String()}
+}
+
+// barrier releases its waiters once n of them are waiting
+type barrier struct {
+	wg   sync.WaitGroup
+	done chan struct{}
+}
+
+func newBarrier(n int) *barrier {
+	b := &barrier{done: make(chan struct{})}
+	b.wg.Add(n)
+	go func() {
+		b.wg.Wait()
+		close(b.done)
+	}()
+	return b
+}
+
+func (b *barrier) wait(ctx context.Context) error {
+	b.wg.Done()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestMultiRead(t *testing.T) {
+	start := time.Now()
+	for _, tc := range []struct {
+		name      string
+		forces    []physic.Force
+		imbalance float64
+	}{
+		{"one cell", []physic.Force{300 * physic.Newton}, 0},
+		{"balanced", []physic.Force{300 * physic.Newton, 300 * physic.Newton}, 0},
+		{"two cells", []physic.Force{300 * physic.Newton, 100 * physic.Newton}, 0.5},
+		{"four cells", []physic.Force{100 * physic.Newton, 150 * physic.Newton, 50 * physic.Newton, 100 * physic.Newton}, 0.25},
+		{"pulling up", []physic.Force{200 * physic.Newton, -100 * physic.Newton}, 3},
+		{"unloaded", []physic.Force{0, 0}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cells []loadcell.Sensor
+			var total physic.Force
+			for i, f := range tc.forces {
+				cells = append(cells, &fakeCell{force: f, time: start.Add(time.Duration(i) * time.Millisecond)})
+				total += f
+			}
+			s, err := loadcell.NewMulti(cells...).Read(context.Background())
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if s.Force != total {
+				t.Errorf("got total force %v, want %v", s.Force, total)
+			}
+			if !reflect.DeepEqual(s.Cells, tc.forces) {
+				t.Errorf("got cells %v, want %v", s.Cells, tc.forces)
+			}
+			if got := s.Imbalance(); math.Abs(got-tc.imbalance) > 1e-9 {
+				t.Errorf("got imbalance %v, want %v", got, tc.imbalance)
+			}
+			// timed by the last cell to be read
+			if want := start.Add(time.Duration(len(tc.forces)-1) * time.Millisecond); !s.Time.Equal(want) {
+				t.Errorf("got time %v, want %v", s.Time, want)
+			}
+		})
+	}
+}
+
+func TestMultiTareConcurrently(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b := newBarrier(4)
+	results := []loadcell.TareResult{
+		{Mean: 100, StdDev: 3, Samples: 10, Rejected: 1},
+		{Mean: -50, StdDev: 4, Samples: 8, Rejected: 0},
+		{Mean: 20, StdDev: 0, Samples: 12, Rejected: 2},
+		{Mean: 30, StdDev: 0, Samples: 10, Rejected: 0},
+	}
+	var cells []loadcell.Sensor
+	for _, r := range results {
+		cells = append(cells, &fakeCell{tare: r, barrier: b})
+	}
+
+	// taring the cells one at a time would leave the first waiting
+	// for the others forever
+	r, err := loadcell.NewMulti(cells...).Tare(ctx, 10)
+	if err != nil {
+		t.Fatalf("Tare: %v", err)
+	}
+	want := loadcell.TareResult{
+		Mean:     100,
+		StdDev:   5,
+		Samples:  8,
+		Rejected: 3,
+		Cells:    results,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
+
+func TestMultiTareError(t *testing.T) {
+	loaded := &loadcell.BoardLoadedError{Load: 100 * physic.Newton}
+	cells := []loadcell.Sensor{
+		&fakeCell{tare: loadcell.TareResult{Mean: 1, Samples: 10}},
+		&fakeCell{tare: loadcell.TareResult{Mean: 2, Samples: 10}, tareErr: loaded},
+		&fakeCell{tareErr: errors.New("later error")},
+	}
+	r, err := loadcell.NewMulti(cells...).Tare(context.Background(), 10)
+	if err != loaded {
+		t.Errorf("got error %v, want the first cell's error %v", err, loaded)
+	}
+	if len(r.Cells) != len(cells) || r.Cells[1].Mean != 2 {
+		t.Errorf("got cells %+v, want every cell's result", r.Cells)
+	}
+}
+
+func TestMultiFanOut(t *testing.T) {
+	cells := []*fakeCell{{force: 1}, {force: 2}, {force: 3}}
+	var sensors []loadcell.Sensor
+	for _, c := range cells {
+		sensors = append(sensors, c)
+	}
+	s := loadcell.NewMulti(sensors...)
+	if err := s.Reset(context.Background()); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if err := s.Halt(); err != nil {
+		t.Fatalf("Halt: %v", err)
+	}
+	for i, c := range cells {
+		if c.resets != 1 || c.halts != 1 {
+			t.Errorf("cell %d reset %d and halted %d times, want once each", i, c.resets, c.halts)
+		}
+	}
+
+	d := loadcell.Describe(s)
+	for i, want := range []string{"1nN", "2nN", "3nN"} {
+		if got := d[fmt.Sprintf("cell%d.force", i)]; got != want {
+			t.Errorf("cell %d described as %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package loadcell
+
+import (
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	// DefaultTareTolerance is the default largest standard deviation
+	// of readings which Tare accepts as stable
+	DefaultTareTolerance = 2 * physic.Newton
+	// DefaultLoadedThreshold is the default largest change from the
+	// previous tare, or from the unloaded reading, which Tare accepts
+	// as an unloaded board
+	DefaultLoadedThreshold = 5 * physic.EarthGravity
+)
+
+// Option configures a Sensor
+type Option interface {
+	apply(s *hx711Sensor)
+}
+
+type optFn func(s *hx711Sensor)
+
+func (fn optFn) apply(s *hx711Sensor) {
+	fn(s)
+}
+
+// WithTareTolerance sets the largest standard deviation of readings
+// which Tare accepts as stable
+func WithTareTolerance(stddev physic.Force) Option {
+	return optFn(func(s *hx711Sensor) {
+		s.tareTolerance = stddev
+	})
+}
+
+// WithUnloadedReading sets the reading measured with nothing on the
+// board, such as when it was calibrated, against which the first Tare
+// checks that the board is unloaded. Without it, the first Tare
+// accepts any steady reading.
+func WithUnloadedReading(raw int64) Option {
+	return optFn(func(s *hx711Sensor) {
+		s.unloaded = raw
+		s.hasUnloaded = true
+	})
+}
+
+// WithLoadedThreshold sets the largest change from the previous tare
+// which Tare accepts as an unloaded board
+func WithLoadedThreshold(f physic.Force) Option {
+	return optFn(func(s *hx711Sensor) {
+		s.loadedThreshold = f
+	})
+}
@@ -1,6 +1,9 @@
 package loadcell
 
 import (
+	"math"
+
+	"github.com/chewr/tension-scale/hx711"
 	"periph.io/x/periph/conn/physic"
 )
 
@@ -17,49 +20,49 @@ type Calibration interface {
 	ToForce(int64) physic.Force
 }
 
-// insensitiveCalibration is intended to calibrate a device for which
-// the minimum reading is greater than the minimum force that can be
-// expressed
-type insensitiveCalibration int64
-
-func (c insensitiveCalibration) ToForce(raw int64) physic.Force {
-	return physic.Force(raw * int64(c))
+// InvertibleCalibration can also convert a force back to the reading
+// which would measure it, for simulation
+type InvertibleCalibration interface {
+	Calibration
+	ToRaw(physic.Force) int64
 }
 
-// sensitiveCalibration is intended to calibrate a device for which
-// the minimum reading is less than the minimum force that can be
-// expressed
-type sensitiveCalibration int64
+// Calibrate returns the calibration for a load cell which reads
+// reading when the given force is applied, and zero without load
+func Calibrate(reading int64, actual physic.Force) Calibration {
+	return LinearCalibration{
+		Slope: float64(actual) / float64(physic.Newton) / float64(reading),
+	}
+}
 
-func (c sensitiveCalibration) ToForce(raw int64) physic.Force {
-	return physic.Force(raw / int64(c))
+// AtGain adapts a calibration made with the hx711 at one gain to
+// readings taken at another, such as from an identical load cell on
+// channel B of a dual channel board
+func AtGain(c Calibration, calibrated, gain hx711.Gain) Calibration {
+	if gain == calibrated || gain.Amplification() == 0 || calibrated.Amplification() == 0 {
+		return c
+	}
+	return gainCalibration{
+		Calibration: c,
+		scale:       float64(calibrated.Amplification()) / float64(gain.Amplification()),
+	}
 }
 
-// amplifiedCalibration is intended to calibrate a device for which
-// the minimum reading is within several orders of magnitude of the
-// minimum force that can be expressed. This is to avoid magnifying
-// integer resolution errors, but reduces the maximum force which
-// can be read.
-type amplifiedCalibration struct {
-	amp, conversion int64
+type gainCalibration struct {
+	Calibration
+	// scale converts readings to what they would be at the gain of
+	// the calibration
+	scale float64
 }
 
-func (c amplifiedCalibration) ToForce(raw int64) physic.Force {
-	amplified := raw * c.amp
-	return physic.Force(amplified / c.conversion)
+func (c gainCalibration) ToForce(raw int64) physic.Force {
+	return c.Calibration.ToForce(int64(math.Round(float64(raw) * c.scale)))
 }
 
-func Calibrate(reading int64, actual physic.Force) Calibration {
-	const minGain = 1000
-	if actual/physic.Force(reading) > minGain {
-		return insensitiveCalibration(actual / physic.Force(reading))
-	}
-	if physic.Force(reading)/actual > minGain {
-		return sensitiveCalibration(physic.Force(reading) / actual)
-	}
-	amplified := reading * minGain
-	return amplifiedCalibration{
-		amp:        minGain,
-		conversion: amplified / int64(actual),
+func (c gainCalibration) ToRaw(f physic.Force) int64 {
+	inv, ok := c.Calibration.(InvertibleCalibration)
+	if !ok {
+		return 0
 	}
+	return toRaw(float64(inv.ToRaw(f)) / c.scale)
 }
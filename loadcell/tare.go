@@ -0,0 +1,107 @@
+package loadcell
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	// outlierMADs is how many scaled median absolute deviations
+	// from the median a reading may be before it is discarded
+	outlierMADs = 3.5
+	// madScale scales the median absolute deviation to estimate the
+	// standard deviation of normally distributed readings
+	madScale = 1.4826
+	// tareWindows bounds how many windows of readings Tare will try
+	// before giving up on the signal settling
+	tareWindows = 5
+)
+
+// TareResult describes the readings a tare was taken from
+type TareResult struct {
+	// Mean is the raw reading with no load
+	Mean float64
+	// StdDev is the standard deviation of the raw readings
+	StdDev float64
+	// Samples is the number of readings the tare was taken from
+	Samples int
+	// Rejected counts the outliers and bad reads which were discarded
+	Rejected int
+	// Cells holds the result for each cell when the sensor combines
+	// several, and is nil otherwise
+	Cells []TareResult
+}
+
+// BoardLoadedError is returned by Tare when the readings show that
+// someone is on the board, either because they are still moving or
+// because they differ from the previous tare
+type BoardLoadedError struct {
+	// Load is the force relative to the previous tare, or zero if
+	// there is no previous tare
+	Load physic.Force
+	// StdDev is the spread of the readings in force units
+	StdDev physic.Force
+}
+
+func (e *BoardLoadedError) Error() string {
+	return fmt.Sprintf("board is loaded (%v ± %v); unload it to tare", e.Load, e.StdDev)
+}
+
+// window holds the most recent readings considered for a tare
+type window struct {
+	size int
+	raw  []float64
+}
+
+func (w *window) add(raw float64) {
+	if len(w.raw) == w.size {
+		copy(w.raw, w.raw[1:])
+		w.raw = w.raw[:w.size-1]
+	}
+	w.raw = append(w.raw, raw)
+}
+
+func (w *window) full() bool {
+	return len(w.raw) == w.size
+}
+
+// stats returns the result over the window with outliers discarded
+func (w *window) stats() TareResult {
+	sorted := append([]float64(nil), w.raw...)
+	sort.Float64s(sorted)
+	med := median(sorted)
+	deviations := make([]float64, len(sorted))
+	for i, x := range sorted {
+		deviations[i] = math.Abs(x - med)
+	}
+	sort.Float64s(deviations)
+	limit := outlierMADs * madScale * median(deviations)
+
+	var r TareResult
+	var sum, sumSq float64
+	for _, x := range sorted {
+		// a zero MAD means most readings agree exactly, and there
+		// is no spread to judge outliers by
+		if limit > 0 && math.Abs(x-med) > limit {
+			r.Rejected++
+			continue
+		}
+		sum += x
+		sumSq += x * x
+		r.Samples++
+	}
+	r.Mean = sum / float64(r.Samples)
+	r.StdDev = math.Sqrt(math.Max(0, sumSq/float64(r.Samples)-r.Mean*r.Mean))
+	return r
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
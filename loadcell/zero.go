@@ -0,0 +1,229 @@
+package loadcell
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+const (
+	// DefaultZeroBand is the default largest force which zero
+	// tracking treats as an unloaded board
+	DefaultZeroBand = 2 * physic.EarthGravity
+	// DefaultZeroHold is how long the force must stay in the zero
+	// band before zero tracking adjusts the tare
+	DefaultZeroHold = 3 * time.Second
+	// DefaultZeroRate is the fraction of the remaining error
+	// corrected by each adjustment
+	DefaultZeroRate = 0.25
+)
+
+// ZeroAdjustment records a correction made by zero tracking
+type ZeroAdjustment struct {
+	Time time.Time
+	// Change is the force subtracted from readings by this adjustment
+	Change physic.Force
+	// Offset is the total force subtracted from readings since the
+	// last tare
+	Offset physic.Force
+}
+
+// ZeroTrackingOption configures a ZeroTracker
+type ZeroTrackingOption interface {
+	apply(z *ZeroTracker)
+}
+
+type zeroOptFn func(z *ZeroTracker)
+
+func (fn zeroOptFn) apply(z *ZeroTracker) {
+	fn(z)
+}
+
+// WithZeroBand sets the largest force treated as an unloaded board
+func WithZeroBand(f physic.Force) ZeroTrackingOption {
+	return zeroOptFn(func(z *ZeroTracker) {
+		z.band = f
+	})
+}
+
+// WithZeroHold sets how long the force must stay steady in the zero
+// band before it is corrected
+func WithZeroHold(d time.Duration) ZeroTrackingOption {
+	return zeroOptFn(func(z *ZeroTracker) {
+		z.hold = d
+	})
+}
+
+// WithZeroRate sets the fraction of the error corrected by each
+// adjustment, between 0 and 1
+func WithZeroRate(rate float64) ZeroTrackingOption {
+	return zeroOptFn(func(z *ZeroTracker) {
+		z.rate = rate
+	})
+}
+
+// ZeroTracker is a Sensor which corrects for drift in the zero point
+// of another. Whenever the force stays steady near zero for a while,
+// as it does while resting, the tare is moved part of the way towards
+// the measured force. Adjustments are cleared by Tare.
+//
+// Only the total force is corrected; ForceSample.Cells are passed
+// through unchanged.
+type ZeroTracker struct {
+	// immutable
+	sensor    Sensor
+	band      physic.Force
+	hold      time.Duration
+	rate      float64
+	tolerance physic.Force
+
+	// mutable
+	mu          sync.Mutex
+	offset      physic.Force
+	tareTime    time.Time
+	window      []ForceSample
+	adjustments []ZeroAdjustment
+}
+
+// NewZeroTracker adds zero tracking to s
+func NewZeroTracker(s Sensor, opts ...ZeroTrackingOption) *ZeroTracker {
+	z := &ZeroTracker{
+		sensor:    s,
+		band:      DefaultZeroBand,
+		hold:      DefaultZeroHold,
+		rate:      DefaultZeroRate,
+		tolerance: DefaultTareTolerance,
+	}
+	for _, opt := range opts {
+		opt.apply(z)
+	}
+	return z
+}
+
+func (z *ZeroTracker) Tare(ctx context.Context, samples int) (TareResult, error) {
+	r, err := z.sensor.Tare(ctx, samples)
+	if err != nil {
+		return r, err
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.offset = 0
+	z.tareTime = time.Now()
+	z.window = z.window[:0]
+	z.adjustments = nil
+	return r, nil
+}
+
+// Describe implements Describer
+func (z *ZeroTracker) Describe() map[string]string {
+	d := map[string]string{}
+	for k, v := range Describe(z.sensor) {
+		d[k] = v
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	d["zero-offset"] = z.offset.String()
+	d["zero-drift"] = z.driftRate().String() + "/min"
+	adjustments := make([]string, len(z.adjustments))
+	for i, a := range z.adjustments {
+		adjustments[i] = fmt.Sprintf("%s %v", a.Time.Format(time.RFC3339Nano), a.Change)
+	}
+	if len(adjustments) == 0 {
+		adjustments = []string{"none"}
+	}
+	d["zero-adjustments"] = strings.Join(adjustments, ", ")
+	return d
+}
+
+func (z *ZeroTracker) Reset(ctx context.Context) error {
+	return z.sensor.Reset(ctx)
+}
+
+func (z *ZeroTracker) Halt() error {
+	return z.sensor.Halt()
+}
+
+func (z *ZeroTracker) Read(ctx context.Context) (ForceSample, error) {
+	fs, err := z.sensor.Read(ctx)
+	if err != nil {
+		return fs, err
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if fs.isFiltered {
+		fs.filtered -= z.offset
+	}
+	fs.Force -= z.offset
+	z.track(fs)
+	return fs, nil
+}
+
+// track considers a corrected sample for adjusting the zero
+func (z *ZeroTracker) track(fs ForceSample) {
+	if fs.Force > z.band || -fs.Force > z.band {
+		z.window = z.window[:0]
+		return
+	}
+	z.window = append(z.window, fs)
+	if fs.Time.Sub(z.window[0].Time) < z.hold {
+		return
+	}
+
+	var sum, sumSq float64
+	for _, s := range z.window {
+		f := float64(s.Force)
+		sum += f
+		sumSq += f * f
+	}
+	n := float64(len(z.window))
+	mean := sum / n
+	stddev := math.Sqrt(math.Max(0, sumSq/n-mean*mean))
+	z.window = z.window[:0]
+	if physic.Force(stddev) > z.tolerance {
+		// still settling; wait for a steadier window
+		return
+	}
+
+	a := ZeroAdjustment{
+		Time:   fs.Time,
+		Change: physic.Force(math.Round(mean * z.rate)),
+	}
+	if a.Change == 0 {
+		return
+	}
+	z.offset += a.Change
+	a.Offset = z.offset
+	z.adjustments = append(z.adjustments, a)
+}
+
+// Adjustments returns the adjustments made since the last tare
+func (z *ZeroTracker) Adjustments() []ZeroAdjustment {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return append([]ZeroAdjustment(nil), z.adjustments...)
+}
+
+// DriftRate returns the rate at which the zero has drifted since the
+// last tare, per minute, as measured by the adjustments made so far
+func (z *ZeroTracker) DriftRate() physic.Force {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.driftRate()
+}
+
+func (z *ZeroTracker) driftRate() physic.Force {
+	if len(z.adjustments) == 0 || z.tareTime.IsZero() {
+		return 0
+	}
+	last := z.adjustments[len(z.adjustments)-1]
+	elapsed := last.Time.Sub(z.tareTime)
+	if elapsed <= 0 {
+		return 0
+	}
+	return physic.Force(float64(last.Offset) / elapsed.Minutes())
+}
@@ -36,6 +36,9 @@ type StreamingSensor interface {
 
 	// ReadContinuous continuously reads as data is
 	// available, sending results over the returned
-	// channel
-	ReadContinuous() <-chan TimeSeriesSample
+	// channel. Reading never blocks on a slow consumer;
+	// samples are buffered and dropped according to
+	// opts instead. The channel is closed when ctx is
+	// done or the sensor is halted.
+	ReadContinuous(ctx context.Context, opts ...StreamOption) <-chan TimeSeriesSample
 }
@@ -0,0 +1,142 @@
+package measurement
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// StreamOption configures ReadContinuous
+type StreamOption interface {
+	apply(cfg *streamConfig)
+}
+
+type streamOptFn func(cfg *streamConfig)
+
+func (fn streamOptFn) apply(cfg *streamConfig) {
+	fn(cfg)
+}
+
+type streamConfig struct {
+	bufferSize int
+	policy     OverflowPolicy
+	drops      *DropCounter
+}
+
+// WithBufferSize sets how many samples are buffered for a slow
+// consumer. The default is 32.
+func WithBufferSize(n int) StreamOption {
+	return streamOptFn(func(cfg *streamConfig) {
+		cfg.bufferSize = n
+	})
+}
+
+// WithOverflowPolicy sets which sample is dropped when the buffer is
+// full. The default is DropOldest.
+func WithOverflowPolicy(policy OverflowPolicy) StreamOption {
+	return streamOptFn(func(cfg *streamConfig) {
+		cfg.policy = policy
+	})
+}
+
+// WithDropCounter counts samples dropped due to overflow in c
+func WithDropCounter(c *DropCounter) StreamOption {
+	return streamOptFn(func(cfg *streamConfig) {
+		cfg.drops = c
+	})
+}
+
+// DropCounter counts samples dropped from a stream
+type DropCounter struct {
+	n uint64
+}
+
+func (c *DropCounter) add(n uint64) {
+	atomic.AddUint64(&c.n, n)
+}
+
+// Dropped returns the number of samples dropped so far
+func (c *DropCounter) Dropped() uint64 {
+	return atomic.LoadUint64(&c.n)
+}
+
+// SampleStream decouples a producer of samples, such as a device
+// driver, from the consumer of a ReadContinuous channel. Pushing a
+// sample never blocks; if the consumer falls behind, samples are
+// buffered and then dropped according to the overflow policy.
+type SampleStream struct {
+	buf   *TimeSeriesBuffer
+	drops *DropCounter
+	out   chan TimeSeriesSample
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewSampleStream starts a stream which delivers samples until ctx
+// is done or Close is called, at which point the channel is closed
+func NewSampleStream(ctx context.Context, opts ...StreamOption) *SampleStream {
+	cfg := &streamConfig{
+		bufferSize: ringBufferLen,
+		policy:     DropOldest,
+	}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	s := &SampleStream{
+		buf:    NewTimeSeriesBuffer(cfg.bufferSize, cfg.policy),
+		drops:  cfg.drops,
+		out:    make(chan TimeSeriesSample),
+		closed: make(chan struct{}),
+	}
+	go s.forward(ctx)
+	return s
+}
+
+// C returns the channel samples are delivered on
+func (s *SampleStream) C() <-chan TimeSeriesSample {
+	return s.out
+}
+
+// Push adds a sample to the stream without blocking
+func (s *SampleStream) Push(ts TimeSeriesSample) {
+	if s.buf.Write(ts) && s.drops != nil {
+		s.drops.add(1)
+	}
+}
+
+// Close stops the stream. Samples which have not yet been delivered
+// are discarded.
+func (s *SampleStream) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// Dropped returns the number of samples lost to overflow
+func (s *SampleStream) Dropped() uint64 {
+	return s.buf.Dropped()
+}
+
+func (s *SampleStream) forward(ctx context.Context) {
+	defer close(s.out)
+	written := s.buf.Written()
+	for {
+		ts, ok := s.buf.Read()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.closed:
+				return
+			case <-written:
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.closed:
+			return
+		case s.out <- ts:
+		}
+	}
+}
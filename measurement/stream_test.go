@@ -0,0 +1,129 @@
+package measurement_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chewr/tension-scale/measurement"
+)
+
+// receive receives n samples from c, failing if they don't arrive
+func receive(t *testing.T, c <-chan measurement.TimeSeriesSample, n int) []measurement.TimeSeriesSample {
+	t.Helper()
+	var got []measurement.TimeSeriesSample
+	for len(got) < n {
+		select {
+		case s, ok := <-c:
+			if !ok {
+				t.Fatalf("stream closed after %d samples, want %d", len(got), n)
+			}
+			got = append(got, s)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d samples, want %d", len(got), n)
+		}
+	}
+	return got
+}
+
+// closed waits for c to be closed, discarding any samples
+func closed(t *testing.T, c <-chan measurement.TimeSeriesSample) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("stream still open")
+		}
+	}
+}
+
+func TestSampleStreamSlowConsumer(t *testing.T) {
+	const (
+		size   = 4
+		pushed = 20
+	)
+	for _, tc := range []struct {
+		name   string
+		policy measurement.OverflowPolicy
+	}{
+		{"drop oldest", measurement.DropOldest},
+		{"drop newest", measurement.DropNewest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var drops measurement.DropCounter
+			s := measurement.NewSampleStream(ctx,
+				measurement.WithBufferSize(size),
+				measurement.WithOverflowPolicy(tc.policy),
+				measurement.WithDropCounter(&drops),
+			)
+			defer s.Close()
+			// nothing is received while pushing, as by a consumer
+			// which has fallen behind
+			for _, ts := range seqs(1, pushed) {
+				s.Push(ts)
+			}
+
+			dropped := s.Dropped()
+			if drops.Dropped() != dropped {
+				t.Errorf("counted %d dropped, want %d", drops.Dropped(), dropped)
+			}
+			// the buffer is full, and a sample may be on its way
+			// to the consumer
+			if dropped != pushed-size && dropped != pushed-size-1 {
+				t.Fatalf("got %d dropped, want %d or %d", dropped, pushed-size, pushed-size-1)
+			}
+			got := receive(t, s.C(), pushed-int(dropped))
+			for i := 1; i < len(got); i++ {
+				if got[i].Seq <= got[i-1].Seq {
+					t.Fatalf("got samples out of order: %v", got)
+				}
+			}
+			switch tc.policy {
+			case measurement.DropOldest:
+				if got[len(got)-1].Seq != pushed || got[len(got)-size].Seq != pushed-size+1 {
+					t.Errorf("got %v, want the newest samples", got)
+				}
+			case measurement.DropNewest:
+				if got[0].Seq != 1 || got[len(got)-1].Seq != uint64(len(got)) {
+					t.Errorf("got %v, want the oldest samples", got)
+				}
+			}
+
+			// once caught up, nothing more is dropped
+			s.Push(measurement.TimeSeriesSample{Seq: pushed + 1})
+			if got := receive(t, s.C(), 1); got[0].Seq != pushed+1 {
+				t.Errorf("got %v, want sample %d", got, pushed+1)
+			}
+			if n := drops.Dropped(); n != dropped {
+				t.Errorf("counted %d dropped after catching up, want %d", n, dropped)
+			}
+		})
+	}
+}
+
+func TestSampleStreamClosesWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := measurement.NewSampleStream(ctx)
+	s.Push(measurement.TimeSeriesSample{Seq: 1})
+	receive(t, s.C(), 1)
+	cancel()
+	closed(t, s.C())
+	// pushing after the stream ends doesn't block
+	s.Push(measurement.TimeSeriesSample{Seq: 2})
+}
+
+func TestSampleStreamClose(t *testing.T) {
+	s := measurement.NewSampleStream(context.Background())
+	s.Push(measurement.TimeSeriesSample{Seq: 1})
+	s.Close()
+	closed(t, s.C())
+	// closing again is harmless
+	s.Close()
+}
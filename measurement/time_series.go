@@ -31,36 +31,126 @@ func (d *TimeSeriesDevice) Read() (TimeSeriesSample, error) {
 type TimeSeriesSample struct {
 	analog.Sample
 	time.Time
+	// Seq numbers the conversion which produced the sample. It
+	// increases monotonically, skipping conversions which were
+	// never read out. It is zero for sensors which do not number
+	// their samples.
+	Seq uint64
+}
+
+// GapDetector counts samples missing from a stream, according to
+// gaps in their sequence numbers
+type GapDetector struct {
+	mu     sync.Mutex
+	last   uint64
+	missed uint64
+}
+
+// Observe records a sample and returns the number of samples missed
+// since the previously observed one
+func (g *GapDetector) Observe(s TimeSeriesSample) uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s.Seq == 0 {
+		return 0
+	}
+	var missed uint64
+	if g.last != 0 && s.Seq > g.last+1 {
+		missed = s.Seq - g.last - 1
+	}
+	if s.Seq > g.last {
+		g.last = s.Seq
+	}
+	g.missed += missed
+	return missed
+}
+
+// Missed returns the total number of samples missed so far
+func (g *GapDetector) Missed() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.missed
 }
 
 const ringBufferLen = 32
 
+// OverflowPolicy decides which sample is lost when a full buffer is
+// written to
+type OverflowPolicy int
+
+const (
+	// DropOldest overwrites the oldest buffered sample
+	DropOldest OverflowPolicy = iota
+	// DropNewest discards the sample being written
+	DropNewest
+)
+
+// TimeSeriesBuffer is a ring buffer of samples. The zero value holds
+// 32 samples and drops the oldest sample on overflow.
 type TimeSeriesBuffer struct {
 	sync.Mutex
-	samples    [ringBufferLen]TimeSeriesSample
+	samples    []TimeSeriesSample
+	policy     OverflowPolicy
 	rptr, wptr int
 	ready      bool
+	dropped    uint64
+	notify     chan struct{}
+}
+
+// NewTimeSeriesBuffer returns a buffer holding up to size samples
+func NewTimeSeriesBuffer(size int, policy OverflowPolicy) *TimeSeriesBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &TimeSeriesBuffer{
+		samples: make([]TimeSeriesSample, size),
+		policy:  policy,
+	}
+}
+
+func (b *TimeSeriesBuffer) init() {
+	if b.samples == nil {
+		b.samples = make([]TimeSeriesSample, ringBufferLen)
+	}
+	if b.notify == nil {
+		b.notify = make(chan struct{}, 1)
+	}
 }
 
-func (b *TimeSeriesBuffer) Write(s TimeSeriesSample) {
+// Write adds a sample to the buffer, dropping a sample according to
+// the overflow policy if the buffer is full. It never blocks, and
+// returns whether a sample was dropped.
+func (b *TimeSeriesBuffer) Write(s TimeSeriesSample) (dropped bool) {
 	b.Lock()
 	defer b.Unlock()
+	b.init()
 	if b.wptr == b.rptr && b.ready {
-		b.rptr = (b.rptr + 1) % ringBufferLen
+		b.dropped++
+		dropped = true
+		if b.policy == DropNewest {
+			return dropped
+		}
+		b.rptr = (b.rptr + 1) % len(b.samples)
 	}
 	b.samples[b.wptr] = s
 	b.ready = true
-	b.wptr = (b.wptr + 1) % ringBufferLen
+	b.wptr = (b.wptr + 1) % len(b.samples)
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	return dropped
 }
 
 func (b *TimeSeriesBuffer) Read() (TimeSeriesSample, bool) {
 	b.Lock()
 	defer b.Unlock()
+	b.init()
 	if !b.ready {
 		return TimeSeriesSample{}, false
 	}
 	ts := b.samples[b.rptr]
-	b.rptr = (b.rptr + 1) % ringBufferLen
+	b.rptr = (b.rptr + 1) % len(b.samples)
 	b.ready = b.rptr != b.wptr
 	return ts, true
 }
@@ -68,16 +158,37 @@ func (b *TimeSeriesBuffer) Read() (TimeSeriesSample, bool) {
 func (b *TimeSeriesBuffer) ReadAll() []TimeSeriesSample {
 	b.Lock()
 	defer b.Unlock()
+	b.init()
 	if !b.ready {
 		return nil
 	}
-	sz := ((b.rptr + ringBufferLen) - b.wptr) % ringBufferLen
+	sz := (b.wptr - b.rptr + len(b.samples)) % len(b.samples)
+	if sz == 0 {
+		// buffer is full
+		sz = len(b.samples)
+	}
 	dt := make([]TimeSeriesSample, sz)
 	for i := range dt {
-		idx := (b.rptr + i) % ringBufferLen
+		idx := (b.rptr + i) % len(b.samples)
 		dt[i] = b.samples[idx]
 	}
-	b.rptr = (b.rptr + sz) % ringBufferLen
+	b.rptr = (b.rptr + sz) % len(b.samples)
 	b.ready = false
 	return dt
 }
+
+// Dropped returns the number of samples lost to overflow
+func (b *TimeSeriesBuffer) Dropped() uint64 {
+	b.Lock()
+	defer b.Unlock()
+	return b.dropped
+}
+
+// Written returns a channel which receives a value after samples
+// have been written to the buffer
+func (b *TimeSeriesBuffer) Written() <-chan struct{} {
+	b.Lock()
+	defer b.Unlock()
+	b.init()
+	return b.notify
+}
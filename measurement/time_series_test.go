@@ -0,0 +1,118 @@
+package measurement_test
+
+import (
+	"testing"
+
+	"github.com/chewr/tension-scale/measurement"
+)
+
+// seqs returns samples numbered first to last
+func seqs(first, last uint64) []measurement.TimeSeriesSample {
+	var samples []measurement.TimeSeriesSample
+	for seq := first; seq <= last; seq++ {
+		samples = append(samples, measurement.TimeSeriesSample{Seq: seq})
+	}
+	return samples
+}
+
+func sameSeqs(a, b []measurement.TimeSeriesSample) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Seq != b[i].Seq {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTimeSeriesBufferOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy measurement.OverflowPolicy
+		want   []measurement.TimeSeriesSample
+	}{
+		{"drop oldest", measurement.DropOldest, seqs(7, 10)},
+		{"drop newest", measurement.DropNewest, seqs(1, 4)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := measurement.NewTimeSeriesBuffer(4, tc.policy)
+			for i, s := range seqs(1, 10) {
+				if dropped := b.Write(s); dropped != (i >= 4) {
+					t.Errorf("writing sample %d: got dropped %v, want %v", s.Seq, dropped, i >= 4)
+				}
+			}
+			if n := b.Dropped(); n != 6 {
+				t.Errorf("got %d dropped, want 6", n)
+			}
+			if got := b.ReadAll(); !sameSeqs(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			if _, ok := b.Read(); ok {
+				t.Errorf("read a sample from an empty buffer")
+			}
+		})
+	}
+}
+
+func TestTimeSeriesBufferWraps(t *testing.T) {
+	var b measurement.TimeSeriesBuffer
+	// the zero value holds 32 samples
+	for _, s := range seqs(1, 32) {
+		if b.Write(s) {
+			t.Fatalf("dropped writing sample %d", s.Seq)
+		}
+	}
+	for seq := uint64(1); seq <= 20; seq++ {
+		if s, ok := b.Read(); !ok || s.Seq != seq {
+			t.Fatalf("Read = %d, %v, want %d", s.Seq, ok, seq)
+		}
+	}
+	for _, s := range seqs(33, 40) {
+		b.Write(s)
+	}
+	if got, want := b.ReadAll(), seqs(21, 40); !sameSeqs(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if n := b.Dropped(); n != 0 {
+		t.Errorf("got %d dropped, want 0", n)
+	}
+}
+
+func TestGapDetector(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		seqs   []uint64
+		missed []uint64
+	}{
+		{"consecutive", []uint64{1, 2, 3}, []uint64{0, 0, 0}},
+		// nothing is known to be missing before the first
+		{"starting late", []uint64{5, 6}, []uint64{0, 0}},
+		{"gaps", []uint64{1, 3, 4, 8}, []uint64{1, 0, 3}},
+		// an old sample neither counts nor moves the sequence back
+		{"out of order", []uint64{1, 3, 2, 4}, []uint64{1, 0, 0}},
+		{"repeated", []uint64{1, 1, 2}, []uint64{0, 0, 0}},
+		// unnumbered samples are ignored
+		{"unnumbered", []uint64{1, 0, 0, 3}, []uint64{0, 0, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var g measurement.GapDetector
+			var total uint64
+			for i, seq := range tc.seqs {
+				missed := g.Observe(measurement.TimeSeriesSample{Seq: seq})
+				var want uint64
+				if i > 0 {
+					want = tc.missed[i-1]
+				}
+				if missed != want {
+					t.Errorf("observing %d: got %d missed, want %d", seq, missed, want)
+				}
+				total += want
+			}
+			if g.Missed() != total {
+				t.Errorf("got %d missed in total, want %d", g.Missed(), total)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package criticalforce implements the critical force test, an
+// all-out intermittent test from which the highest sustainable force
+// and the capacity to work above it are estimated. The analysis
+// follows Giles et al., "The Determination of Finger-Flexor Critical
+// Force in Rock Climbers" (2019).
+package criticalforce
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/analysis"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"periph.io/x/periph/conn/physic"
+)
+
+// endReps is the number of final contractions whose mean force is
+// taken as the critical force
+const endReps = 6
+
+var (
+	ErrInvalidProtocol = errors.New("critical force test needs positive contraction and rest times")
+	ErrNotEnoughReps   = fmt.Errorf("critical force test needs more than %d contractions", endReps)
+)
+
+// Protocol describes a critical force test
+type Protocol struct {
+	// On is how long each contraction lasts
+	On time.Duration
+	// Off is the rest between contractions
+	Off time.Duration
+	// Reps is the number of contractions
+	Reps int
+}
+
+// Standard is the test of Giles et al.: 24 contractions of 7s with
+// 3s rests
+var Standard = Protocol{On: 7 * time.Second, Off: 3 * time.Second, Reps: 24}
+
+// Workout returns the test
+func Workout(p Protocol) (isometric.Workout, error) {
+	if p.On <= 0 || p.Off <= 0 {
+		return nil, ErrInvalidProtocol
+	}
+	if p.Reps <= endReps {
+		return nil, ErrNotEnoughReps
+	}
+	return interval.Composite(
+		interval.SetupInterval(time.Minute),
+		interval.CriticalForceTest(p.On, p.Off, p.Reps),
+	), nil
+}
+
+// Analyze estimates critical force and W' from the mean force of each
+// contraction of length on. Critical force is the mean of the final
+// contractions, by which point the force has fallen to a plateau, and
+// W' is the impulse above critical force over the whole test.
+func Analyze(reps []physic.Force, on time.Duration) (history.CriticalForceResult, error) {
+	if len(reps) <= endReps {
+		return history.CriticalForceResult{}, ErrNotEnoughReps
+	}
+	var sum float64
+	for _, f := range reps[len(reps)-endReps:] {
+		sum += float64(f)
+	}
+	cf := physic.Force(sum / endReps)
+
+	var wPrime float64
+	for _, f := range reps {
+		if f > cf {
+			wPrime += float64(f-cf) / float64(physic.Newton) * on.Seconds()
+		}
+	}
+	return history.CriticalForceResult{
+		CriticalForce: cf,
+		WPrime:        wPrime,
+		Reps:          reps,
+	}, nil
+}
+
+// Report writes a summary of a test to w
+func Report(w io.Writer, r history.CriticalForceResult) error {
+	if _, err := fmt.Fprintf(w, "%s\n\n", r.Workout); err != nil {
+		return err
+	}
+	for i, f := range r.Reps {
+		if _, err := fmt.Fprintf(w, "  rep %2d  %v\n", i+1, f); err != nil {
+			return err
+		}
+	}
+	peak := physic.Force(0)
+	for _, f := range r.Reps {
+		if f > peak {
+			peak = f
+		}
+	}
+	var ratio float64
+	if peak > 0 {
+		ratio = 100 * float64(r.CriticalForce) / float64(peak)
+	}
+	if _, err := fmt.Fprintf(w, "\ncritical force  %v (%.0f%% of peak contraction)\nW'              %.0fN·s\n", r.CriticalForce, ratio, r.WPrime); err != nil {
+		return err
+	}
+	fatigue, err := analysis.FatigueIndex(r.Reps)
+	if err != nil {
+		// there are no reps to compare
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "fatigue index   %.0f%%\n", 100*fatigue)
+	return err
+}
@@ -0,0 +1,165 @@
+package criticalforce
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/analysis"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"github.com/chewr/tension-scale/loadcell"
+	"periph.io/x/periph/conn/physic"
+)
+
+// MissedRepError is returned when a critical force test finishes but
+// can't be analyzed, because one of its contractions was skipped,
+// recorded no samples or was not recorded at all
+type MissedRepError struct {
+	Test string
+	Rep  int
+}
+
+func (e *MissedRepError) Error() string {
+	return fmt.Sprintf("%s not analyzed: contraction %d was skipped or recorded no force", e.Test, e.Rep)
+}
+
+type recorder struct {
+	store  history.CriticalForceStore
+	report func(history.CriticalForceResult)
+
+	mu    sync.Mutex
+	test  string
+	start time.Time
+	reps  []physic.Force
+	// missed is the first contraction of the test which was not
+	// recorded, or 0
+	missed int
+}
+
+// Recorder analyzes critical force tests as their contractions are
+// recorded. When the last contraction of a test finishes, the result
+// is stored and passed to report, or if a contraction was missed, a
+// MissedRepError is returned. Other workouts are ignored.
+func Recorder(store history.CriticalForceStore, report func(history.CriticalForceResult)) isometric.WorkoutRecorder {
+	return &recorder{
+		store:  store,
+		report: report,
+	}
+}
+
+func (r *recorder) Start(_ context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
+	rep, ok := interval.ParseCriticalForceRep(descriptor)
+	if !ok {
+		return nopUpdater{}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rep.Test != r.test || rep.Rep == 1 {
+		r.test = rep.Test
+		r.start = time.Time{}
+		r.reps = make([]physic.Force, 0, rep.Reps)
+		r.missed = 0
+	}
+	return &repUpdater{recorder: r, rep: rep}, nil
+}
+
+// finishRep adds the mean force of a contraction, or notes that it
+// was skipped or recorded no samples if !ok, analyzing the test once
+// it is complete
+func (r *recorder) finishRep(rep interval.CriticalForceRep, start time.Time, mean physic.Force, ok bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rep.Test != r.test {
+		return nil
+	}
+	if r.missed == 0 && rep.Rep != len(r.reps)+1 {
+		r.missed = len(r.reps) + 1
+	}
+	if r.missed == 0 && !ok {
+		r.missed = rep.Rep
+	}
+	if r.missed == 0 {
+		if r.start.IsZero() {
+			r.start = start
+		}
+		r.reps = append(r.reps, mean)
+	}
+	if rep.Rep < rep.Reps {
+		return nil
+	}
+	if r.missed != 0 {
+		r.test = ""
+		return &MissedRepError{Test: rep.Test, Rep: r.missed}
+	}
+	result, err := Analyze(r.reps, rep.On)
+	if err != nil {
+		return err
+	}
+	result.Time = r.start
+	result.Workout = r.test
+	r.test = ""
+	if err := r.store.Record(result); err != nil {
+		return err
+	}
+	if r.report != nil {
+		r.report(result)
+	}
+	return nil
+}
+
+type repUpdater struct {
+	recorder *recorder
+	rep      interval.CriticalForceRep
+
+	mu      sync.Mutex
+	samples []loadcell.ForceSample
+	closed  bool
+}
+
+func (u *repUpdater) Write(samples ...loadcell.ForceSample) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.samples = append(u.samples, samples...)
+	return nil
+}
+
+func (u *repUpdater) Finish(outcome isometric.WorkoutOutcome) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.closed = true
+	sort.Slice(u.samples, func(i, j int) bool {
+		return u.samples[i].Time.Before(u.samples[j].Time)
+	})
+	if outcome.Result == isometric.Skipped {
+		return u.recorder.finishRep(u.rep, time.Time{}, 0, false)
+	}
+	mean, err := analysis.Mean(u.samples)
+	if err == analysis.ErrNoSamples {
+		return u.recorder.finishRep(u.rep, time.Time{}, 0, false)
+	} else if err != nil {
+		return err
+	}
+	return u.recorder.finishRep(u.rep, u.samples[0].Time, mean, true)
+}
+
+func (u *repUpdater) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closed = true
+}
+
+type nopUpdater struct{}
+
+func (nopUpdater) Write(...loadcell.ForceSample) error   { return nil }
+func (nopUpdater) Finish(isometric.WorkoutOutcome) error { return nil }
+func (nopUpdater) Close()                                {}
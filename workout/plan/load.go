@@ -0,0 +1,258 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"gopkg.in/yaml.v3"
+	"periph.io/x/periph/conn/physic"
+)
+
+var (
+	ErrNoMax = errors.New("threshold is a percentage of max, but max is not known")
+)
+
+// Load reads a plan from YAML or JSON. Unknown fields are an error,
+// so that a misspelt option isn't silently ignored.
+func Load(r io.Reader) (*Plan, error) {
+	// JSON is YAML, so one decoder does for both
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+	p := new(Plan)
+	if err := d.Decode(p); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Build returns the workout described by the plan. Thresholds given
+// as a percentage are of max, which may be zero if there are none.
+func (p *Plan) Build(max physic.Force) (isometric.Workout, error) {
+	b := &builder{
+		plan:   p,
+		max:    max,
+		active: make(map[string]bool),
+	}
+	return b.steps("workout", p.Workout)
+}
+
+type builder struct {
+	plan *Plan
+	max  physic.Force
+	// active holds the blocks being built, to catch recursion
+	active map[string]bool
+}
+
+func (b *builder) steps(path string, steps []Step) (isometric.Workout, error) {
+	workouts := make([]isometric.Workout, 0, len(steps))
+	for i, s := range steps {
+		w, err := b.step(fmt.Sprintf("%s[%d]", path, i), s)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	return interval.Composite(workouts...), nil
+}
+
+func (b *builder) step(path string, s Step) (isometric.Workout, error) {
+	w, err := b.single(path, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Repeat < 0 || s.Sets < 0 {
+		return nil, fmt.Errorf("%s: repeat and sets must not be negative", path)
+	}
+	if s.Repeat > 1 {
+		reps := make([]isometric.Workout, s.Repeat)
+		for i := range reps {
+			reps[i] = w
+		}
+		w = interval.Composite(reps...)
+	}
+	if s.Sets > 1 {
+		var setRest time.Duration
+		if s.SetRest != "" {
+			if setRest, err = parseDuration(path+".set-rest", s.SetRest); err != nil {
+				return nil, err
+			}
+		}
+		sets := []isometric.Workout{w}
+		for i := 1; i < s.Sets; i++ {
+			if setRest > 0 {
+				sets = append(sets, interval.RestInterval(setRest))
+			}
+			sets = append(sets, w)
+		}
+		w = interval.Composite(sets...)
+	}
+	return w, nil
+}
+
+// single builds the step without its repeats or sets
+func (b *builder) single(path string, s Step) (isometric.Workout, error) {
+	var (
+		kinds []string
+		w     isometric.Workout
+		err   error
+	)
+	if s.Setup != "" {
+		kinds = append(kinds, "setup")
+		var d time.Duration
+		if d, err = parseDuration(path+".setup", s.Setup); err == nil {
+			w = interval.SetupInterval(d)
+		}
+	}
+	if s.Rest != "" {
+		kinds = append(kinds, "rest")
+		var d time.Duration
+		if d, err = parseDuration(path+".rest", s.Rest); err == nil {
+			w = interval.RestInterval(d)
+		}
+	}
+	if s.MaxTest != "" {
+		kinds = append(kinds, "max-test")
+		var d time.Duration
+		if d, err = parseDuration(path+".max-test", s.MaxTest); err == nil {
+			w = interval.MaxTest(d)
+		}
+	}
+	if s.Work != nil {
+		kinds = append(kinds, "work")
+		w, err = b.work(path+".work", s.Work)
+	}
+	if s.Block != "" {
+		kinds = append(kinds, "block")
+		w, err = b.block(path+".block", s.Block)
+	}
+	if len(s.Intervals) > 0 {
+		kinds = append(kinds, "intervals")
+		w, err = b.steps(path+".intervals", s.Intervals)
+	}
+	switch {
+	case len(kinds) == 0:
+		return nil, fmt.Errorf("%s: step is empty", path)
+	case len(kinds) > 1:
+		return nil, fmt.Errorf("%s: step has more than one of %s", path, strings.Join(kinds, ", "))
+	}
+	return w, err
+}
+
+func (b *builder) block(path, name string) (isometric.Workout, error) {
+	steps, ok := b.plan.Blocks[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no block named %q", path, name)
+	}
+	if b.active[name] {
+		return nil, fmt.Errorf("%s: block %q uses itself", path, name)
+	}
+	b.active[name] = true
+	defer delete(b.active, name)
+	return b.steps(fmt.Sprintf("blocks.%s", name), steps)
+}
+
+func (b *builder) work(path string, w *Work) (isometric.Workout, error) {
+	d, err := parseDuration(path+".duration", w.Duration)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := b.force(path+".threshold", w.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	var opts []interval.WorkOption
+	if w.Hysteresis != "" {
+		band, err := b.force(path+".hysteresis", w.Hysteresis)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, interval.WithHysteresis(band))
+	}
+	if w.Dwell != "" {
+		dwell, err := parseDuration(path+".dwell", w.Dwell)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, interval.WithDwell(dwell))
+	}
+	if w.Dip != "" {
+		policy, err := parseDip(path+".dip", w.Dip)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, interval.WithDipPolicy(policy))
+	}
+	return interval.WorkInterval(threshold, d, opts...), nil
+}
+
+// force parses a force as for ParseForce, or a percentage of max
+func (b *builder) force(path, s string) (physic.Force, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasSuffix(s, "%") {
+		f, err := ParseForce(s)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", path, err)
+		}
+		return f, nil
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", path, err)
+	}
+	if b.max <= 0 {
+		return 0, fmt.Errorf("%s: %v", path, ErrNoMax)
+	}
+	return physic.Force(float64(b.max) * pct / 100), nil
+}
+
+// ParseForce parses a force in N or lbf with an optional SI prefix,
+// or the weight of a mass in kg
+func ParseForce(s string) (physic.Force, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "kg") {
+		kg, err := strconv.ParseFloat(strings.TrimSuffix(s, "kg"), 64)
+		if err != nil {
+			return 0, err
+		}
+		return physic.Force(kg * float64(physic.EarthGravity)), nil
+	}
+	var f physic.Force
+	if err := f.Set(s); err != nil {
+		return 0, err
+	}
+	return f, nil
+}
+
+func parseDuration(path, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", path, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s: duration must be positive", path)
+	}
+	return d, nil
+}
+
+func parseDip(path, s string) (interval.DipPolicy, error) {
+	switch {
+	case s == "pause":
+		return interval.PauseOnDip, nil
+	case s == "fail":
+		return interval.FailOnDip, nil
+	case strings.HasPrefix(s, "grace:"):
+		d, err := parseDuration(path, strings.TrimPrefix(s, "grace:"))
+		if err != nil {
+			return interval.DipPolicy{}, err
+		}
+		return interval.GraceOnDip(d), nil
+	}
+	return interval.DipPolicy{}, fmt.Errorf("%s: %q is not pause, fail or grace:<duration>", path, s)
+}
@@ -0,0 +1,60 @@
+// Package plan loads isometric workouts from YAML or JSON files, so
+// that protocols can be written without recompiling. For example:
+//
+//	name: max hangs week 1
+//	blocks:
+//	  ladder:
+//	    - work: {duration: 3s, threshold: 80%}
+//	    - rest: 30s
+//	    - work: {duration: 6s, threshold: 80%}
+//	    - rest: 30s
+//	    - work: {duration: 9s, threshold: 80%}
+//	workout:
+//	  - setup: 1m
+//	  - block: ladder
+//	    sets: 3
+//	    set-rest: 90s
+//
+// Each step is exactly one of setup, rest, max-test, work, block or
+// intervals. Durations use Go syntax, such as 1m30s. Thresholds are
+// a force such as 400N, 90lbf or 40kg, or a percentage of the
+// athlete's max.
+package plan
+
+// Plan is a workout definition
+type Plan struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Blocks are named lists of steps which can be used by name
+	Blocks  map[string][]Step `json:"blocks,omitempty" yaml:"blocks,omitempty"`
+	Workout []Step            `json:"workout" yaml:"workout"`
+}
+
+// Step is one step of a workout
+type Step struct {
+	Setup   string `json:"setup,omitempty" yaml:"setup,omitempty"`
+	Rest    string `json:"rest,omitempty" yaml:"rest,omitempty"`
+	MaxTest string `json:"max-test,omitempty" yaml:"max-test,omitempty"`
+	Work    *Work  `json:"work,omitempty" yaml:"work,omitempty"`
+	// Block names a block to run
+	Block string `json:"block,omitempty" yaml:"block,omitempty"`
+	// Intervals is a group of steps to run
+	Intervals []Step `json:"intervals,omitempty" yaml:"intervals,omitempty"`
+
+	// Repeat runs the step this many times back to back
+	Repeat int `json:"repeat,omitempty" yaml:"repeat,omitempty"`
+	// Sets runs the repeated step this many times, resting for
+	// SetRest between each
+	Sets    int    `json:"sets,omitempty" yaml:"sets,omitempty"`
+	SetRest string `json:"set-rest,omitempty" yaml:"set-rest,omitempty"`
+}
+
+// Work is a work interval
+type Work struct {
+	Duration  string `json:"duration" yaml:"duration"`
+	Threshold string `json:"threshold" yaml:"threshold"`
+	// Hysteresis, Dwell and Dip are optional; see interval.WorkOption
+	Hysteresis string `json:"hysteresis,omitempty" yaml:"hysteresis,omitempty"`
+	Dwell      string `json:"dwell,omitempty" yaml:"dwell,omitempty"`
+	// Dip is one of pause, fail or grace:<duration>
+	Dip string `json:"dip,omitempty" yaml:"dip,omitempty"`
+}
@@ -0,0 +1,55 @@
+// Package repeaters implements repeater, or density, hang workouts:
+// sets of short hangs with shorter rests between them, such as 7:3
+// (seven seconds on, three off) or 10:5, which train strength
+// endurance
+package repeaters
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"periph.io/x/periph/conn/physic"
+)
+
+var ErrInvalidProtocol = errors.New("repeaters need positive hang and rest times, reps and sets")
+
+// Protocol describes a repeaters workout
+type Protocol struct {
+	// On is how long each rep is hung for
+	On time.Duration
+	// Off is the rest between reps
+	Off time.Duration
+	// Reps is the number of reps in a set
+	Reps int
+	// Sets is the number of sets
+	Sets int
+	// SetRest is the rest between sets
+	SetRest time.Duration
+}
+
+var (
+	// SevenThree is six sets of six 7:3 repeaters
+	SevenThree = Protocol{On: 7 * time.Second, Off: 3 * time.Second, Reps: 6, Sets: 6, SetRest: 3 * time.Minute}
+	// TenFive is five sets of six 10:5 repeaters
+	TenFive = Protocol{On: 10 * time.Second, Off: 5 * time.Second, Reps: 6, Sets: 5, SetRest: 3 * time.Minute}
+)
+
+func (p Protocol) String() string {
+	return fmt.Sprintf("%v:%v x%d x%d", p.On, p.Off, p.Reps, p.Sets)
+}
+
+// Workout returns the protocol at the given load
+func Workout(p Protocol, load physic.Force) (isometric.Workout, error) {
+	if p.On <= 0 || p.Off <= 0 || p.Reps <= 0 || p.Sets <= 0 || p.SetRest < 0 {
+		return nil, ErrInvalidProtocol
+	}
+	set := interval.Repeaters(load, p.On, p.Off, p.Reps)
+	sets := []isometric.Workout{interval.SetupInterval(time.Minute), set}
+	for i := 1; i < p.Sets; i++ {
+		sets = append(sets, interval.RestInterval(p.SetRest), set)
+	}
+	return interval.Composite(sets...), nil
+}
@@ -0,0 +1,97 @@
+package rfdtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/analysis/rfd"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/interval"
+	"github.com/chewr/tension-scale/loadcell"
+)
+
+type recorder struct {
+	store history.RFDStore
+	w     io.Writer
+}
+
+// Recorder analyzes each pull of a rate of force development test as
+// it finishes, storing the result and writing a report of it to w.
+// Other workouts are ignored.
+func Recorder(store history.RFDStore, w io.Writer) isometric.WorkoutRecorder {
+	return &recorder{
+		store: store,
+		w:     w,
+	}
+}
+
+func (r *recorder) Start(_ context.Context, descriptor string) (isometric.WorkoutUpdater, error) {
+	if _, _, ok := interval.ParseExplosivePull(descriptor); !ok {
+		return nopUpdater{}, nil
+	}
+	return &pullUpdater{recorder: r, name: descriptor}, nil
+}
+
+type pullUpdater struct {
+	recorder *recorder
+	name     string
+
+	mu      sync.Mutex
+	samples []loadcell.ForceSample
+	closed  bool
+}
+
+func (u *pullUpdater) Write(samples ...loadcell.ForceSample) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.samples = append(u.samples, samples...)
+	return nil
+}
+
+func (u *pullUpdater) Finish(outcome isometric.WorkoutOutcome) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return isometric.ErrWriteAfterClosed
+	}
+	u.closed = true
+	if outcome.Result == isometric.Skipped {
+		return nil
+	}
+	sort.Slice(u.samples, func(i, j int) bool {
+		return u.samples[i].Time.Before(u.samples[j].Time)
+	})
+	m, err := rfd.Analyze(u.samples)
+	switch err {
+	case nil:
+	case rfd.ErrNoBaseline, rfd.ErrNoOnset:
+		_, err := fmt.Fprintf(u.recorder.w, "%s: %v\n", u.name, err)
+		return err
+	default:
+		return err
+	}
+	result := history.RFDResult{Metrics: m, Workout: u.name}
+	if err := u.recorder.store.Record(result); err != nil {
+		return err
+	}
+	return Report(u.recorder.w, result)
+}
+
+func (u *pullUpdater) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closed = true
+}
+
+type nopUpdater struct{}
+
+func (nopUpdater) Write(...loadcell.ForceSample) error   { return nil }
+func (nopUpdater) Finish(isometric.WorkoutOutcome) error { return nil }
+func (nopUpdater) Close()                                {}
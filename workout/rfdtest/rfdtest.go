@@ -0,0 +1,61 @@
+// Package rfdtest implements a rate of force development test: a few
+// short maximal pulls, each analyzed for how quickly force rises
+package rfdtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chewr/tension-scale/isometric"
+	"github.com/chewr/tension-scale/isometric/analysis/rfd"
+	"github.com/chewr/tension-scale/isometric/history"
+	"github.com/chewr/tension-scale/isometric/interval"
+)
+
+var ErrInvalidProtocol = errors.New("rate of force development test needs at least one pull and positive pull and rest times")
+
+// Protocol describes a rate of force development test
+type Protocol struct {
+	// Reps is the number of pulls
+	Reps int
+	// Pull is how long each pull lasts
+	Pull time.Duration
+	// Rest is the rest between pulls
+	Rest time.Duration
+}
+
+// Standard is five 3s pulls with 30s rests
+var Standard = Protocol{Reps: 5, Pull: 3 * time.Second, Rest: 30 * time.Second}
+
+// Workout returns the test
+func Workout(p Protocol) (isometric.Workout, error) {
+	if p.Reps <= 0 || p.Pull <= 0 || p.Rest <= 0 {
+		return nil, ErrInvalidProtocol
+	}
+	return interval.Composite(
+		interval.SetupInterval(time.Minute),
+		interval.ExplosivePulls(p.Reps, p.Pull, p.Rest),
+	), nil
+}
+
+// Report writes a summary of a pull to w
+func Report(w io.Writer, r history.RFDResult) error {
+	if _, err := fmt.Fprintf(w, "%s\n", r.Workout); err != nil {
+		return err
+	}
+	for i, window := range rfd.Windows {
+		if i >= len(r.RFD) {
+			break
+		}
+		label := fmt.Sprintf("RFD 0-%dms", window/time.Millisecond)
+		if _, err := fmt.Fprintf(w, "  %-14s %v\n", label, r.RFD[i]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "  peak RFD       %v at %v\n  peak force     %v at %v\n",
+		r.PeakRFD, r.TimeToPeakRFD.Round(time.Millisecond),
+		r.PeakForce, r.TimeToPeak.Round(time.Millisecond))
+	return err
+}